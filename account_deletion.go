@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+// purgeDeletedAccounts hard-deletes accounts whose grace period is over.
+// Everything they own goes with them through ON DELETE CASCADE.
+func purgeDeletedAccounts(db *database.Queries, gracePeriod time.Duration) {
+	for range time.Tick(time.Hour) {
+		purged, err := db.PurgeDeletedUsers(context.Background(), gracePeriod.Seconds())
+		if err != nil {
+			log.Printf("could not purge deleted accounts: %v", err)
+			continue
+		}
+		for _, userID := range purged {
+			log.Printf("purged deleted account %s", userID)
+		}
+	}
+}
+
+// deletionScheduledAt is when an account that asked to be deleted will be
+// purged, or nil if it hasn't asked.
+func (cfg *apiConfig) deletionScheduledAt(user database.User) *time.Time {
+	if !user.DeletionRequestedAt.Valid {
+		return nil
+	}
+	t := user.DeletionRequestedAt.Time.Add(cfg.accountDeletionGracePeriod)
+	return &t
+}
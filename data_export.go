@@ -0,0 +1,169 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// dataExportTTL is how long a finished export can be downloaded.
+	dataExportTTL     = 7 * 24 * time.Hour
+	dataExportTimeout = 5 * time.Minute
+)
+
+// buildDataExport collects everything Chirpy keeps about the export's user
+// into a ZIP of JSON files and stores it on the export.
+func (cfg *apiConfig) buildDataExport(export database.DataExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+	defer cancel()
+
+	archive, err := cfg.dataExportArchive(ctx, export)
+	if err != nil {
+		log.Printf("could not build data export %s: %v", export.ID, err)
+		err = cfg.db.FailDataExport(ctx, export.ID)
+		if err != nil {
+			log.Printf("could not mark data export %s failed: %v", export.ID, err)
+		}
+		return
+	}
+
+	err = cfg.db.CompleteDataExport(ctx, database.CompleteDataExportParams{
+		ID:      export.ID,
+		Archive: archive,
+	})
+	if err != nil {
+		log.Printf("could not store data export %s: %v", export.ID, err)
+	}
+}
+
+func (cfg *apiConfig) dataExportArchive(ctx context.Context, export database.DataExport) ([]byte, error) {
+	type profile struct {
+		User
+		TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	}
+	type follows struct {
+		Following []Follow `json:"following"`
+		Followers []Follow `json:"followers"`
+	}
+	type like struct {
+		ChirpID uuid.UUID `json:"chirp_id"`
+		LikedAt time.Time `json:"liked_at"`
+	}
+
+	user, err := cfg.db.GetUserById(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByAuthor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDatabase(dbChirp)
+	}
+
+	dbTokens, err := cfg.db.ListSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		sessions[i] = sessionFromDatabase(dbToken)
+	}
+
+	dbLikes, err := cfg.db.GetLikesByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	likes := make([]like, len(dbLikes))
+	for i, dbLike := range dbLikes {
+		likes[i] = like{
+			ChirpID: dbLike.ChirpID,
+			LikedAt: dbLike.CreatedAt,
+		}
+	}
+
+	dbFollowing, err := cfg.db.GetFollowing(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	dbFollowers, err := cfg.db.GetFollowers(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	userFollows := follows{
+		Following: make([]Follow, len(dbFollowing)),
+		Followers: make([]Follow, len(dbFollowers)),
+	}
+	for i, follow := range dbFollowing {
+		userFollows.Following[i] = Follow{
+			UserID:     follow.FolloweeID,
+			FollowedAt: follow.CreatedAt,
+		}
+	}
+	for i, follow := range dbFollowers {
+		userFollows.Followers[i] = Follow{
+			UserID:     follow.FollowerID,
+			FollowedAt: follow.CreatedAt,
+		}
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile{
+			User:                userFromDatabase(user),
+			TwoFactorEnabled:    user.TotpEnabledAt.Valid,
+			DeletionScheduledAt: cfg.deletionScheduledAt(user),
+		}},
+		{"chirps.json", chirps},
+		{"sessions.json", sessions},
+		{"likes.json", likes},
+		{"follows.json", userFollows},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(file.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func pruneDataExports(db *database.Queries) {
+	for range time.Tick(time.Hour) {
+		_, err := db.DeleteExpiredDataExports(context.Background())
+		if err != nil {
+			log.Printf("could not prune data exports: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/brettlazarine/Chirpy/internal/mailer"
+	"github.com/google/uuid"
+)
+
+const (
+	// emailVerificationTTL is how long a verification link stays valid. A
+	// user who misses it can ask for a new one.
+	emailVerificationTTL = 48 * time.Hour
+	sendMailTimeout      = 30 * time.Second
+)
+
+// mailerFromEnv picks how email is sent from MAILER: "log" (the default)
+// writes messages to MAIL_LOG_FILE or stderr for development, "smtp" relays
+// them through SMTP_HOST.
+func mailerFromEnv() mailer.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Chirpy <noreply@localhost>"
+	}
+
+	switch name := os.Getenv("MAILER"); name {
+	case "", "log":
+		path := os.Getenv("MAIL_LOG_FILE")
+		if path == "" {
+			return mailer.NewLogMailer(os.Stderr, from)
+		}
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("error opening mail log: %v", err)
+		}
+		return mailer.NewLogMailer(file, from)
+	case "smtp":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			log.Fatalf("SMTP_HOST environment variable is required when MAILER is smtp")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return mailer.NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+	default:
+		log.Fatalf("MAILER must be log or smtp: %q", name)
+		return nil
+	}
+}
+
+// sendVerificationEmail mails userID a link proving they own email. Each
+// link is backed by its own record, so it works once and only while the
+// account still has that email.
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	verification, err := cfg.db.CreateEmailVerification(ctx, database.CreateEmailVerificationParams{
+		UserID: userID,
+		Email:  email,
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.MakeEmailVerificationJWT(userID, verification.ID, cfg.jwtKeyring, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := cfg.publicURL + "/app/verify.html?token=" + url.QueryEscape(token)
+	ctx, cancel := context.WithTimeout(ctx, sendMailTimeout)
+	defer cancel()
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your Chirpy email address",
+		Body: fmt.Sprintf("Confirm that this is your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %d hours. If you didn't sign up for Chirpy, you can ignore this email.\n",
+			link, int(emailVerificationTTL.Hours())),
+	})
+}
+
+// requireVerifiedEmail responds with 403 and returns false when the server
+// only lets verified users post and userID hasn't verified their email.
+func (cfg *apiConfig) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	if !cfg.verifiedEmailRequired {
+		return true
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return false
+	}
+	if !user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "verify your email address first", nil)
+		return false
+	}
+	return true
+}
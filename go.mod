@@ -11,3 +11,5 @@ require (
 require golang.org/x/crypto v0.32.0
 
 require github.com/golang-jwt/jwt/v5 v5.2.1
+
+require golang.org/x/sys v0.29.0 // indirect
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxAPITokenNameLength = 100
+
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func apiTokenFromDatabase(dbToken database.ApiToken) APIToken {
+	token := APIToken{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		Name:      dbToken.Name,
+		Scopes:    dbToken.Scopes,
+	}
+	if dbToken.ExpiresAt.Valid {
+		token.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return token
+}
+
+// handlerCreateAPIToken issues a personal access token. The token itself is
+// only ever returned here; afterwards just its hash is stored.
+func (cfg *apiConfig) handlerCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	type response struct {
+		APIToken
+		Token string `json:"token"`
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	if params.Name == "" || len(params.Name) > maxAPITokenNameLength {
+		respondWithError(w, http.StatusBadRequest, "name must be between 1 and 100 characters", nil)
+		return
+	}
+	if params.ExpiresInDays < 0 {
+		respondWithError(w, http.StatusBadRequest, "expires_in_days must not be negative", nil)
+		return
+	}
+	scopes, err := auth.ValidateScopes(params.Scopes)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Tokens without expires_in_days never expire.
+	expiresAt := sql.NullTime{}
+	if params.ExpiresInDays > 0 {
+		expiresAt = sql.NullTime{
+			Time:  time.Now().Add(time.Duration(params.ExpiresInDays) * 24 * time.Hour),
+			Valid: true,
+		}
+	}
+
+	token, err := auth.MakeAPIToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create token", err)
+		return
+	}
+
+	dbToken, err := cfg.db.CreateApiToken(r.Context(), database.CreateApiTokenParams{
+		UserID:    userID,
+		Name:      params.Name,
+		TokenHash: auth.HashAPIToken(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response{
+		APIToken: apiTokenFromDatabase(dbToken),
+		Token:    token,
+	})
+}
+
+func (cfg *apiConfig) handlerGetAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	dbTokens, err := cfg.db.ListApiTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get tokens", err)
+		return
+	}
+
+	tokens := make([]APIToken, 0, len(dbTokens))
+	for _, dbToken := range dbTokens {
+		tokens = append(tokens, apiTokenFromDatabase(dbToken))
+	}
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (cfg *apiConfig) handlerRevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error parsing token id", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeApiToken(r.Context(), database.RevokeApiTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke token", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "token not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -13,27 +15,60 @@ import (
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	Edited     bool       `json:"edited"`
+	UserId     uuid.UUID  `json:"user_id"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to"`
+	ReplyCount int32      `json:"reply_count"`
+	LikeCount  int32      `json:"like_count"`
+	LikedByMe  *bool      `json:"liked_by_me,omitempty"`
+
+	// RechirpOf is set for rechirps and quotes. A quote whose original has
+	// been deleted keeps IsQuote but loses RechirpOf, and is reported with
+	// OriginalDeleted so clients can render a tombstone.
+	RechirpOf       *uuid.UUID `json:"rechirp_of"`
+	IsQuote         bool       `json:"is_quote"`
+	Original        *Chirp     `json:"original,omitempty"`
+	OriginalDeleted bool       `json:"original_deleted,omitempty"`
+}
+
+func chirpFromDatabase(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:         dbChirp.ID,
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		Edited:     !dbChirp.UpdatedAt.Equal(dbChirp.CreatedAt),
+		UserId:     dbChirp.UserID,
+		ReplyCount: dbChirp.ReplyCount,
+		LikeCount:  dbChirp.LikeCount,
+	}
+	if dbChirp.InReplyTo.Valid {
+		chirp.InReplyTo = &dbChirp.InReplyTo.UUID
+	}
+	if dbChirp.RechirpOf.Valid {
+		chirp.RechirpOf = &dbChirp.RechirpOf.UUID
+	}
+	chirp.IsQuote = dbChirp.IsQuote
+	chirp.OriginalDeleted = dbChirp.IsQuote && !dbChirp.RechirpOf.Valid
+	return chirp
 }
 
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string     `json:"body"`
+		InReplyTo *uuid.UUID `json:"in_reply_to"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userId, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not get token", err)
+		respondWithAuthError(w, err)
 		return
 	}
-
-	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+	if !cfg.requireVerifiedEmail(w, r, userId) {
 		return
 	}
 
@@ -48,24 +83,42 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 	cleaned, err := validateChirp(params.Body)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userId,
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpById(r.Context(), *params.InReplyTo)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusBadRequest, "chirp being replied to does not exist", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not get chirp being replied to", err)
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:      cleaned,
+			UserID:    userId,
+			InReplyTo: inReplyTo,
+		})
+		if err != nil {
+			return err
+		}
+		return syncChirpMetadata(r.Context(), q, chirp.ID, chirp.Body)
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not create chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
-	})
+	respondWithJSON(w, http.StatusCreated, chirpFromDatabase(chirp))
 }
 
 func validateChirp(body string) (string, error) {
@@ -83,6 +136,19 @@ func validateChirp(body string) (string, error) {
 	return cleaned, nil
 }
 
+// syncChirpMetadata re-derives everything stored about a chirp from its body:
+// its hashtags and the users it mentions. It should run in the same
+// transaction as the write that changed the body, so a failure here can't
+// leave the chirp saved with stale metadata.
+func syncChirpMetadata(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	err := syncChirpHashtags(ctx, q, chirpID, body)
+	if err != nil {
+		return err
+	}
+
+	return syncChirpMentions(ctx, q, chirpID, body)
+}
+
 func getCleanedBody(body string, badWords map[string]struct{}) string {
 	words := strings.Split(body, " ")
 	for i, word := range words {
@@ -7,6 +7,8 @@ import (
 	"github.com/google/uuid"
 )
 
+// handlerDeleteChirp deletes one of the caller's chirps. Pure rechirps of it
+// are deleted with it; quotes of it stay up with original_deleted set.
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
 	chirpID := r.PathValue("chirpID")
 	if chirpID == "" {
@@ -19,15 +21,9 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not get token", err)
-		return
-	}
-
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		respondWithAuthError(w, err)
 		return
 	}
 
@@ -1,57 +1,93 @@
 package main
 
 import (
+	"context"
 	"net/http"
-	"sort"
+	"strconv"
 
 	"github.com/brettlazarine/Chirpy/internal/database"
 	"github.com/google/uuid"
 )
 
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	author_id := r.URL.Query().Get("author_id")
-	var dbChirps []database.Chirp
-	var err error
-	if author_id != "" {
-		authorId, err := uuid.Parse(author_id)
+	viewerID, err := cfg.optionalUserID(r)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	authorID := uuid.NullUUID{}
+	if author_id := query.Get("author_id"); author_id != "" {
+		id, err := uuid.Parse(author_id)
 		if err != nil {
 			respondWithError(w, http.StatusBadRequest, "error parsing author id", err)
 			return
 		}
-		dbChirps, err = cfg.db.GetChirpsByAuthor(r.Context(), authorId)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
-			return
-		}
-	} else {
-		dbChirps, err = cfg.db.GetAllChirps(r.Context())
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
-			return
-		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
 	}
 
-	chirps := make([]Chirp, len(dbChirps))
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 
-	for i, chirp := range dbChirps {
-		chirps[i] = Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserId:    chirp.UserID,
+	cursor, err := decodeChirpCursor(query.Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	includeRechirps := true
+	if include_rechirps := query.Get("include_rechirps"); include_rechirps != "" {
+		includeRechirps, err = strconv.ParseBool(include_rechirps)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "include_rechirps must be true or false", err)
+			return
 		}
 	}
 
-	sortType := r.URL.Query().Get("sort")
-	if sortType == "asc" || sortType == "" {
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
+	// Fetch one extra row so we know whether there is a next page.
+	var dbChirps []database.Chirp
+	sortType := query.Get("sort")
+	switch sortType {
+	case "", "asc":
+		dbChirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursor.createdAt(),
+			CursorID:        cursor.id(),
+			IncludeRechirps: includeRechirps,
+			Limit:           limit + 1,
 		})
-	} else if sortType == "desc" {
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
+	case "desc":
+		dbChirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: cursor.createdAt(),
+			CursorID:        cursor.id(),
+			IncludeRechirps: includeRechirps,
+			Limit:           limit + 1,
 		})
+	default:
+		respondWithError(w, http.StatusBadRequest, "sort must be asc or desc", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	if len(dbChirps) > int(limit) {
+		dbChirps = dbChirps[:limit]
+		last := dbChirps[len(dbChirps)-1]
+		setNextPageLink(w, r, encodeChirpCursor(last.CreatedAt, last.ID))
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), dbChirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, chirps)
@@ -71,15 +107,82 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserId:    dbChirp.UserID,
+	viewerID, err := cfg.optionalUserID(r)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), []database.Chirp{dbChirp}, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+// chirpsForViewer converts chirps for a response, embedding the originals of
+// rechirps and quotes and filling in the viewer's own state (such as
+// liked_by_me) when the request is authenticated.
+func (cfg *apiConfig) chirpsForViewer(ctx context.Context, dbChirps []database.Chirp, viewerID uuid.NullUUID) ([]Chirp, error) {
+	chirps := make([]Chirp, len(dbChirps))
+	chirpIDs := make([]uuid.UUID, 0, len(dbChirps))
+	originalIDs := []uuid.UUID{}
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDatabase(dbChirp)
+		chirpIDs = append(chirpIDs, dbChirp.ID)
+		if dbChirp.RechirpOf.Valid {
+			originalIDs = append(originalIDs, dbChirp.RechirpOf.UUID)
+		}
+	}
+
+	originals := make(map[uuid.UUID]Chirp, len(originalIDs))
+	if len(originalIDs) > 0 {
+		dbOriginals, err := cfg.db.GetChirpsByIds(ctx, originalIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, dbOriginal := range dbOriginals {
+			originals[dbOriginal.ID] = chirpFromDatabase(dbOriginal)
+			chirpIDs = append(chirpIDs, dbOriginal.ID)
+		}
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewerID.Valid && len(chirpIDs) > 0 {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	for i := range chirps {
+		if viewerID.Valid {
+			likedByMe := liked[chirps[i].ID]
+			chirps[i].LikedByMe = &likedByMe
+		}
+		if chirps[i].RechirpOf == nil {
+			continue
+		}
+		original, ok := originals[*chirps[i].RechirpOf]
+		if !ok {
+			continue
+		}
+		if viewerID.Valid {
+			likedByMe := liked[original.ID]
+			original.LikedByMe = &likedByMe
+		}
+		chirps[i].Original = &original
 	}
 
-	respondWithJSON(w, http.StatusOK, chirp)
+	return chirps, nil
 }
 
 // *** Boot.dev Implementation
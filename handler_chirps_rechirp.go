@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerRechirp re-shares a chirp. Without a body it is a pure rechirp, which
+// a user can only make once per chirp; with a body it is a quote chirp.
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID format", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+	if !cfg.requireVerifiedEmail(w, r, userID) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	original, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "chirp not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+
+	// Rechirping a pure rechirp shares the chirp it points at.
+	if original.RechirpOf.Valid && !original.IsQuote {
+		original, err = cfg.db.GetChirpById(r.Context(), original.RechirpOf.UUID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusNotFound, "chirp not found", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+			return
+		}
+	}
+
+	isQuote := params.Body != ""
+	body := ""
+	if isQuote {
+		body, err = validateChirp(params.Body)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	var dbChirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		dbChirp, err = q.CreateRechirp(r.Context(), database.CreateRechirpParams{
+			Body:      body,
+			UserID:    userID,
+			RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+			IsQuote:   isQuote,
+		})
+		if err != nil {
+			return err
+		}
+		return syncChirpMetadata(r.Context(), q, dbChirp.ID, dbChirp.Body)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "chirp already rechirped", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not rechirp", err)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, chirps[0])
+}
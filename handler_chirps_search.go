@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := cfg.optionalUserID(r)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	tsQuery, err := buildSearchQuery(query.Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if author_id := query.Get("author_id"); author_id != "" {
+		id, err := uuid.Parse(author_id)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "error parsing author id", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:    tsQuery,
+		AuthorID: authorID,
+		Limit:    limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not search chirps", err)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), dbChirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// buildSearchQuery turns user input into a to_tsquery expression. Every term
+// must match; "quoted words" become a phrase and a trailing * makes a term
+// match as a prefix. Anything that isn't a letter or digit is dropped, so user
+// input can never produce a tsquery syntax error.
+func buildSearchQuery(q string) (string, error) {
+	var terms []string
+
+	for _, part := range splitSearchInput(q) {
+		words := strings.Fields(part.text)
+		var lexemes []string
+		for _, word := range words {
+			prefix := !part.phrase && strings.HasSuffix(word, "*")
+			lexeme := strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return unicode.ToLower(r)
+				}
+				return -1
+			}, word)
+			if lexeme == "" {
+				continue
+			}
+			if prefix {
+				lexeme += ":*"
+			}
+			lexemes = append(lexemes, lexeme)
+		}
+		if len(lexemes) == 0 {
+			continue
+		}
+
+		if part.phrase && len(lexemes) > 1 {
+			terms = append(terms, "("+strings.Join(lexemes, " <-> ")+")")
+		} else {
+			terms = append(terms, lexemes...)
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("search query is empty")
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+type searchInputPart struct {
+	text   string
+	phrase bool
+}
+
+// splitSearchInput separates double-quoted phrases from the loose words
+// around them. An unterminated quote runs to the end of the input.
+func splitSearchInput(q string) []searchInputPart {
+	var parts []searchInputPart
+	phrase := false
+	for _, segment := range strings.Split(q, `"`) {
+		if strings.TrimSpace(segment) != "" {
+			parts = append(parts, searchInputPart{text: segment, phrase: phrase})
+		}
+		phrase = !phrase
+	}
+	return parts
+}
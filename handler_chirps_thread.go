@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxThreadDepth   = 20
+	maxThreadReplies = 500
+)
+
+type ChirpReply struct {
+	Chirp
+	Replies []*ChirpReply `json:"replies"`
+}
+
+type ChirpThread struct {
+	Ancestors []Chirp       `json:"ancestors"`
+	Chirp     Chirp         `json:"chirp"`
+	Replies   []*ChirpReply `json:"replies"`
+}
+
+// handlerGetChirpThread returns the conversation around a chirp: the chain of
+// chirps it replies to (root first) and the tree of replies below it.
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error parsing chirp id", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "chirp not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+
+	dbAncestors, err := cfg.db.GetChirpAncestors(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get thread", err)
+		return
+	}
+
+	dbReplies, err := cfg.db.GetChirpReplies(r.Context(), database.GetChirpRepliesParams{
+		ChirpID:  chirpID,
+		MaxDepth: maxThreadDepth,
+		Limit:    maxThreadReplies,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get thread", err)
+		return
+	}
+
+	viewerID, err := cfg.optionalUserID(r)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	dbThread := make([]database.Chirp, 0, len(dbAncestors)+1+len(dbReplies))
+	dbThread = append(dbThread, dbAncestors...)
+	dbThread = append(dbThread, dbChirp)
+	dbThread = append(dbThread, dbReplies...)
+	thread, err := cfg.chirpsForViewer(r.Context(), dbThread, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get thread", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ChirpThread{
+		Ancestors: thread[:len(dbAncestors)],
+		Chirp:     thread[len(dbAncestors)],
+		Replies:   buildReplyTree(chirpID, thread[len(dbAncestors)+1:]),
+	})
+}
+
+// buildReplyTree nests replies under their parents. Replies are expected in
+// creation order, which guarantees a parent is seen before its children; a
+// reply whose parent was cut off by the depth or size limit is dropped.
+func buildReplyTree(rootID uuid.UUID, replies []Chirp) []*ChirpReply {
+	roots := []*ChirpReply{}
+	nodes := make(map[uuid.UUID]*ChirpReply, len(replies))
+
+	for _, reply := range replies {
+		if reply.InReplyTo == nil {
+			continue
+		}
+		node := &ChirpReply{
+			Chirp:   reply,
+			Replies: []*ChirpReply{},
+		}
+
+		parentID := *reply.InReplyTo
+		if parentID == rootID {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[parentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		} else {
+			continue
+		}
+		nodes[reply.ID] = node
+	}
+
+	return roots
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type ChirpRevision struct {
+	ID        uuid.UUID `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handlerUpdateChirp replaces the body of one of the caller's chirps. The
+// previous body is kept in the chirp's revision history.
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	chirpID := r.PathValue("chirpID")
+	if chirpID == "" {
+		respondWithError(w, http.StatusNotFound, "missing id parameter", nil)
+		return
+	}
+	uuidChirpID, err := uuid.Parse(chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp ID format", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsWrite)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpById(r.Context(), uuidChirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "could not get chirp", err)
+		return
+	}
+
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "user does not own chirp", nil)
+		return
+	}
+
+	if chirp.RechirpOf.Valid && !chirp.IsQuote {
+		respondWithError(w, http.StatusBadRequest, "rechirps cannot be edited", nil)
+		return
+	}
+
+	cleaned, err := validateChirp(params.Body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var updated database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		updated, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:   uuidChirpID,
+			Body: cleaned,
+		})
+		if err != nil {
+			return err
+		}
+		return syncChirpMetadata(r.Context(), q, updated.ID, updated.Body)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update chirp", err)
+		return
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), []database.Chirp{updated}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "error parsing chirp id", err)
+		return
+	}
+
+	_, err = cfg.db.GetChirpById(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return
+	}
+
+	dbRevisions, err := cfg.db.GetChirpRevisions(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get revisions", err)
+		return
+	}
+
+	revisions := make([]ChirpRevision, len(dbRevisions))
+	for i, revision := range dbRevisions {
+		revisions[i] = ChirpRevision{
+			ID:        revision.ID,
+			Body:      revision.Body,
+			CreatedAt: revision.CreatedAt,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, revisions)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token string `json:"token"`
+	}
+	type response struct {
+		User
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	userID, verificationID, err := auth.ValidateEmailVerificationJWT(params.Token, cfg.jwtKeyring, cfg.jwtLeeway)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid or expired verification token", err)
+		return
+	}
+
+	verification, err := cfg.db.UseEmailVerification(r.Context(), database.UseEmailVerificationParams{
+		ID:     verificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "verification token has already been used", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not verify email", err)
+		return
+	}
+
+	_, err = cfg.db.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+		ID:    userID,
+		Email: verification.Email,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not verify email", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	// Nothing was marked if the email changed after the link was sent.
+	if user.Email != verification.Email {
+		respondWithError(w, http.StatusBadRequest, "email has changed since this link was sent", nil)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		User: userFromDatabase(user),
+	})
+}
+
+func (cfg *apiConfig) handlerResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	if user.EmailVerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "email is already verified", nil)
+		return
+	}
+
+	err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not send verification email", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user ID format", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	if followeeID == userID {
+		respondWithError(w, http.StatusBadRequest, "users cannot follow themselves", nil)
+		return
+	}
+
+	// Accounts waiting to be purged can't be followed, just as they can't
+	// be seen.
+	followee, err := cfg.db.GetUserById(r.Context(), followeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	if followee.DeletionRequestedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "user not found", nil)
+		return
+	}
+
+	err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not follow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user ID format", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	err = cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetFollowers lists the users following userID, most recent follow
+// first.
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, limit, cursor, ok := parseFollowListRequest(w, r)
+	if !ok {
+		return
+	}
+
+	dbFollows, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.createdAt(),
+		CursorID:        cursor.id(),
+		Limit:           limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get followers", err)
+		return
+	}
+
+	follows := make([]Follow, len(dbFollows))
+	for i, follow := range dbFollows {
+		follows[i] = Follow{
+			UserID:     follow.FollowerID,
+			FollowedAt: follow.CreatedAt,
+		}
+	}
+
+	respondWithFollowPage(w, r, follows, limit)
+}
+
+// handlerGetFollowing lists the users userID follows, most recent follow
+// first.
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, limit, cursor, ok := parseFollowListRequest(w, r)
+	if !ok {
+		return
+	}
+
+	dbFollows, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.createdAt(),
+		CursorID:        cursor.id(),
+		Limit:           limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get following", err)
+		return
+	}
+
+	follows := make([]Follow, len(dbFollows))
+	for i, follow := range dbFollows {
+		follows[i] = Follow{
+			UserID:     follow.FolloweeID,
+			FollowedAt: follow.CreatedAt,
+		}
+	}
+
+	respondWithFollowPage(w, r, follows, limit)
+}
+
+// parseFollowListRequest reads the user ID and paging parameters shared by the
+// follower and following lists, responding with 400 when any are invalid.
+func parseFollowListRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, int32, *chirpCursor, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user ID format", err)
+		return uuid.Nil, 0, nil, false
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return uuid.Nil, 0, nil, false
+	}
+
+	cursor, err := decodeChirpCursor(query.Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return uuid.Nil, 0, nil, false
+	}
+
+	return userID, limit, cursor, true
+}
+
+// respondWithFollowPage trims the extra row fetched to detect a next page.
+// Follows are keyed by the other user's ID, which is what the cursor carries
+// alongside the follow time.
+func respondWithFollowPage(w http.ResponseWriter, r *http.Request, follows []Follow, limit int32) {
+	if len(follows) > int(limit) {
+		follows = follows[:limit]
+		last := follows[len(follows)-1]
+		setNextPageLink(w, r, encodeChirpCursor(last.FollowedAt, last.UserID))
+	}
+
+	respondWithJSON(w, http.StatusOK, follows)
+}
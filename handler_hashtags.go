@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+type TrendingHashtag struct {
+	Name     string  `json:"name"`
+	LastHour int64   `json:"last_hour"`
+	LastDay  int64   `json:"last_day"`
+	Velocity float64 `json:"velocity"`
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := normalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag", nil)
+		return
+	}
+
+	viewerID, err := cfg.optionalUserID(r)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	cursor, err := decodeChirpCursor(query.Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+		Name:            tag,
+		CursorCreatedAt: cursor.createdAt(),
+		CursorID:        cursor.id(),
+		Limit:           limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	if len(dbChirps) > int(limit) {
+		dbChirps = dbChirps[:limit]
+		last := dbChirps[len(dbChirps)-1]
+		setNextPageLink(w, r, encodeChirpCursor(last.CreatedAt, last.ID))
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), dbChirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// handlerGetTrendingHashtags ranks tags used in the last day by velocity: how
+// often they were used in the last hour compared to their hourly average over
+// the whole day. A velocity above 1 means a tag is picking up.
+func (cfg *apiConfig) handlerGetTrendingHashtags(w http.ResponseWriter, r *http.Request) {
+	limit := defaultTrendingLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive integer", err)
+			return
+		}
+		if limit > maxTrendingLimit {
+			limit = maxTrendingLimit
+		}
+	}
+
+	dbTrending, err := cfg.db.GetTrendingHashtags(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get trending hashtags", err)
+		return
+	}
+
+	trending := make([]TrendingHashtag, len(dbTrending))
+	for i, hashtag := range dbTrending {
+		trending[i] = TrendingHashtag{
+			Name:     hashtag.Name,
+			LastHour: hashtag.LastHour,
+			LastDay:  hashtag.LastDay,
+			Velocity: hashtag.Velocity,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, trending)
+}
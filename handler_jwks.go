@@ -0,0 +1,14 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handlerJWKS publishes the public keys that access tokens are signed with,
+// so other services can verify them without sharing a secret.
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	// Short enough that a newly added key is picked up well before it
+	// becomes the active signing key.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.jwtKeyring.JWKS())
+}
@@ -1,23 +1,31 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/brettlazarine/Chirpy/internal/auth"
 	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
 )
 
+// mfaChallengeTTL is how long a user has to enter their second factor after
+// entering the right password.
+const mfaChallengeTTL = 5 * time.Minute
+
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
 	}
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
+	type mfaResponse struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -28,19 +36,65 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	accountKey := "email:" + strings.ToLower(strings.TrimSpace(params.Email))
+	if !cfg.reserveLoginAttempt(w, r, accountKey) {
+		return
+	}
+
+	// Unknown emails and wrong passwords get the same response, and take
+	// the same time, so logins can't be used to find out who has an account.
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+			return
+		}
+		cfg.passwordHasher.Check(params.Password, cfg.dummyPasswordHash)
+		respondWithError(w, http.StatusUnauthorized, "incorrect email or password", err)
 		return
 	}
 
-	err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	err = cfg.passwordHasher.Check(params.Password, user.HashedPassword)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid password", err)
+		respondWithError(w, http.StatusUnauthorized, "incorrect email or password", err)
+		return
+	}
+	cfg.recordLoginSuccess(r, accountKey)
+
+	cfg.rehashPasswordIfNeeded(r, user, params.Password)
+
+	if user.TotpEnabledAt.Valid {
+		mfaToken, err := auth.MakeMFAChallengeJWT(user.ID, cfg.jwtKeyring, mfaChallengeTTL)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create token", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, mfaResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		})
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	cfg.issueSession(w, r, user)
+}
+
+// issueSession starts a new session for a user who has fully authenticated
+// and responds with the user and their access and refresh tokens.
+func (cfg *apiConfig) issueSession(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+
+		// DeletionScheduledAt is set while the account is waiting to be
+		// deleted, so clients can offer to cancel with POST
+		// /api/users/me/restore.
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	}
+
+	sessionID := uuid.New()
+	accessToken, err := auth.MakeSessionJWT(user.ID, sessionID, cfg.jwtKeyring, cfg.accessTokenTTL)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not create token", err)
 		return
@@ -53,9 +107,14 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
+		TokenHash: auth.HashRefreshToken(refreshToken),
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 60),
+		ExpiresAt: time.Now().Add(cfg.refreshTokenTTL),
+		FamilyID:  sessionID,
+
+		UserAgent:        r.UserAgent(),
+		IpAddress:        clientIP(r),
+		SessionStartedAt: time.Now(),
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not create refresh token", err)
@@ -63,14 +122,36 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			Id:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User:         userFromDatabase(user),
 		Token:        accessToken,
 		RefreshToken: refreshToken,
+
+		DeletionScheduledAt: cfg.deletionScheduledAt(user),
 	})
 }
+
+// rehashPasswordIfNeeded upgrades a stored hash that uses bcrypt or outdated
+// argon2id parameters, now that the password is known. A failure only means
+// the upgrade waits for the next login.
+func (cfg *apiConfig) rehashPasswordIfNeeded(r *http.Request, user database.User, password string) {
+	if !cfg.passwordHasher.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	newHash, err := cfg.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("could not rehash password for user %s: %v", user.ID, err)
+		return
+	}
+
+	// Matching on the old hash keeps a concurrent password change from being
+	// overwritten.
+	_, err = cfg.db.RehashUserPassword(r.Context(), database.RehashUserPasswordParams{
+		NewHash: newHash,
+		ID:      user.ID,
+		OldHash: user.HashedPassword,
+	})
+	if err != nil {
+		log.Printf("could not store rehashed password for user %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetMentions lists chirps that mention the authenticated user, newest
+// first.
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, auth.ScopeChirpsRead)
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parsePageLimit(query.Get("limit"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	cursor, err := decodeChirpCursor(query.Get("cursor"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.GetMentions(r.Context(), database.GetMentionsParams{
+		UserID:          userID,
+		CursorCreatedAt: cursor.createdAt(),
+		CursorID:        cursor.id(),
+		Limit:           limit + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get mentions", err)
+		return
+	}
+
+	if len(dbChirps) > int(limit) {
+		dbChirps = dbChirps[:limit]
+		last := dbChirps[len(dbChirps)-1]
+		setNextPageLink(w, r, encodeChirpCursor(last.CreatedAt, last.ID))
+	}
+
+	chirps, err := cfg.chirpsForViewer(r.Context(), dbChirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get mentions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
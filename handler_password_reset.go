@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/brettlazarine/Chirpy/internal/mailer"
+)
+
+const (
+	passwordResetTTL = 30 * time.Minute
+	// passwordResetCooldown limits how often anyone can make Chirpy email
+	// reset links to the same account.
+	passwordResetCooldown = time.Minute
+)
+
+// handlerForgotPassword always answers 202, and mails the link in the
+// background, so neither the response nor its timing reveals whether the
+// email has an account.
+func (cfg *apiConfig) handlerForgotPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), sendMailTimeout)
+	go func() {
+		defer cancel()
+		err := cfg.sendPasswordResetEmail(ctx, params.Email)
+		if err != nil {
+			log.Printf("could not send password reset email: %v", err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (cfg *apiConfig) sendPasswordResetEmail(ctx context.Context, email string) error {
+	user, err := cfg.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	recent, err := cfg.db.CountRecentPasswordResets(ctx, database.CountRecentPasswordResetsParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().Add(-passwordResetCooldown),
+	})
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return nil
+	}
+
+	token, err := auth.MakePasswordResetToken()
+	if err != nil {
+		return err
+	}
+	_, err = cfg.db.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: auth.HashPasswordResetToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := cfg.publicURL + "/app/reset-password.html?token=" + url.QueryEscape(token)
+	return cfg.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your Chirpy account. To choose a new password, open this link:\n\n%s\n\n"+
+			"The link expires in %d minutes and works once. Resetting your password signs you out everywhere and revokes your personal access tokens. "+
+			"If you didn't ask for this, you can ignore this email.\n",
+			link, int(passwordResetTTL.Minutes())),
+	})
+}
+
+// handlerResetPassword sets a new password for the holder of a reset token,
+// logs out every session and revokes every personal access token, since
+// whoever knew the old password may have made some. The response says how
+// many of each were revoked. Two-factor authentication still applies at the
+// next login.
+func (cfg *apiConfig) handlerResetPassword(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	type response struct {
+		RevokedRefreshTokens int64 `json:"revoked_refresh_tokens"`
+		RevokedApiTokens     int64 `json:"revoked_api_tokens"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	reset, err := cfg.db.GetPasswordResetByToken(r.Context(), auth.HashPasswordResetToken(params.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "invalid or expired reset token", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get reset token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), reset.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+
+	// The policy is checked before the token is used up, so a rejected
+	// password can be fixed without asking for another email.
+	err = cfg.passwordPolicy.Check(params.Password, user.Email, user.Username.String)
+	if err != nil {
+		respondWithPasswordPolicyError(w, err)
+		return
+	}
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not hash password", err)
+		return
+	}
+
+	used, err := cfg.db.UsePasswordReset(r.Context(), reset.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not use reset token", err)
+		return
+	}
+	if used == 0 {
+		respondWithError(w, http.StatusBadRequest, "invalid or expired reset token", nil)
+		return
+	}
+
+	user, err = cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
+		ID:             user.ID,
+		Email:          user.Email,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+		return
+	}
+
+	revokedSessions, err := cfg.db.RevokeUserRefreshTokens(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke sessions", err)
+		return
+	}
+	revokedTokens, err := cfg.db.RevokeUserApiTokens(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke personal access tokens", err)
+		return
+	}
+	err = cfg.db.InvalidatePasswordResets(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not invalidate reset tokens", err)
+		return
+	}
+	// The token could only have been read from the user's inbox, which is
+	// as good as following a verification link.
+	_, err = cfg.db.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+	if err != nil {
+		log.Printf("could not mark email verified for user %s: %v", user.ID, err)
+	}
+
+	cfg.recordSecurityEvent(r.Context(), user.ID, securityEventPasswordReset,
+		fmt.Sprintf("password reset by email; revoked %d refresh token(s) and %d personal access token(s)",
+			revokedSessions, revokedTokens))
+
+	respondWithJSON(w, http.StatusOK, response{
+		RevokedRefreshTokens: revokedSessions,
+		RevokedApiTokens:     revokedTokens,
+	})
+}
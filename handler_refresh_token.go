@@ -1,15 +1,24 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
 )
 
+// handlerRefreshToken trades a refresh token for a new access token and a new
+// refresh token. The presented token is revoked, so each refresh token works
+// exactly once. If an already-used token comes back, someone else has a copy
+// of it, and every token in its family is revoked.
 func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request) {
 	type response struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	refreshToken, err := auth.GetBearerToken(r.Header)
@@ -18,23 +27,74 @@ func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	usedToken, err := cfg.db.UseRefreshToken(r.Context(), auth.HashRefreshToken(refreshToken))
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not get user from refresh token", err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "could not use refresh token", err)
+			return
+		}
+		cfg.handleRejectedRefreshToken(r, refreshToken)
+		respondWithError(w, http.StatusUnauthorized, "invalid refresh token", err)
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	accessToken, err := auth.MakeSessionJWT(usedToken.UserID, usedToken.FamilyID, cfg.jwtKeyring, cfg.accessTokenTTL)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not create token", err)
 		return
 	}
 
+	newRefreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create refresh token", err)
+		return
+	}
+
+	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		TokenHash: auth.HashRefreshToken(newRefreshToken),
+		UserID:    usedToken.UserID,
+		ExpiresAt: time.Now().Add(cfg.refreshTokenTTL),
+		FamilyID:  usedToken.FamilyID,
+
+		UserAgent:        usedToken.UserAgent,
+		IpAddress:        usedToken.IpAddress,
+		SessionStartedAt: usedToken.SessionStartedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create refresh token", err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, response{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
 	})
 }
 
+// handleRejectedRefreshToken looks at why a refresh token couldn't be used. A
+// token that was revoked while its family is still live has been replayed, so
+// the whole family is revoked and the reuse is recorded.
+func (cfg *apiConfig) handleRejectedRefreshToken(r *http.Request, refreshToken string) {
+	token, err := cfg.db.GetRefreshTokenByToken(r.Context(), auth.HashRefreshToken(refreshToken))
+	if err != nil || !token.RevokedAt.Valid {
+		return
+	}
+
+	revoked, err := cfg.db.RevokeRefreshTokenFamily(r.Context(), token.FamilyID)
+	if err != nil {
+		cfg.recordSecurityEvent(r.Context(), token.UserID, securityEventRefreshTokenReuse,
+			fmt.Sprintf("revoked refresh token reused; could not revoke family %s: %v", token.FamilyID, err))
+		return
+	}
+	if revoked == 0 {
+		// The family was already dead, e.g. after a logout.
+		return
+	}
+
+	cfg.recordSecurityEvent(r.Context(), token.UserID, securityEventRefreshTokenReuse,
+		fmt.Sprintf("revoked refresh token reused; revoked %d live token(s) in family %s", revoked, token.FamilyID))
+}
+
 func (cfg *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -42,7 +102,18 @@ func (cfg *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	token, err := cfg.db.RevokeRefreshToken(r.Context(), auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "invalid refresh token", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not revoke token", err)
+		return
+	}
+
+	// Logging out ends the whole session, not just this link in the chain.
+	_, err = cfg.db.RevokeRefreshTokenFamily(r.Context(), token.FamilyID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not revoke token", err)
 		return
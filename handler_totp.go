@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/auth"
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+const totpIssuer = "Chirpy"
+
+// handlerEnrollTOTP starts two-factor enrollment by generating a new secret.
+// Login doesn't ask for a code until the secret is activated with one.
+func (cfg *apiConfig) handlerEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled", nil)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create secret", err)
+		return
+	}
+
+	err = cfg.db.SetTotpSecret(r.Context(), database.SetTotpSecretParams{
+		ID:         userID,
+		TotpSecret: nullString(&secret),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save secret", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// handlerActivateTOTP turns on two-factor authentication once the user shows
+// their authenticator produces valid codes, and hands out recovery codes.
+func (cfg *apiConfig) handlerActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	if user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusConflict, "two-factor authentication is already enabled", nil)
+		return
+	}
+	if !user.TotpSecret.Valid {
+		respondWithError(w, http.StatusBadRequest, "two-factor enrollment has not been started", nil)
+		return
+	}
+
+	step, err := auth.ValidateTOTP(user.TotpSecret.String, params.Code, time.Now())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid code", err)
+		return
+	}
+
+	recoveryCodes, err := auth.MakeRecoveryCodes()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create recovery codes", err)
+		return
+	}
+	codeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		codeHashes = append(codeHashes, auth.HashRecoveryCode(code))
+	}
+
+	err = cfg.db.DeleteRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create recovery codes", err)
+		return
+	}
+	err = cfg.db.CreateRecoveryCodes(r.Context(), database.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: codeHashes,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create recovery codes", err)
+		return
+	}
+
+	err = cfg.db.EnableTotp(r.Context(), database.EnableTotpParams{
+		ID:           userID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not enable two-factor authentication", err)
+		return
+	}
+	cfg.recordSecurityEvent(r.Context(), userID, securityEventTOTPEnabled, "two-factor authentication enabled")
+
+	respondWithJSON(w, http.StatusOK, response{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// handlerLoginMFA finishes a login that handlerLogin answered with an MFA
+// challenge. It takes either a TOTP code or one of the recovery codes.
+func (cfg *apiConfig) handlerLoginMFA(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	userID, err := auth.ValidateMFAChallengeJWT(params.MFAToken, cfg.jwtKeyring, cfg.jwtLeeway)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "invalid mfa token", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	if !user.TotpEnabledAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "two-factor authentication is not enabled", nil)
+		return
+	}
+
+	// Codes are short, so guesses are throttled just like passwords.
+	accountKey := "mfa:" + user.ID.String()
+	if !cfg.reserveLoginAttempt(w, r, accountKey) {
+		return
+	}
+
+	switch {
+	case params.Code != "":
+		err = cfg.useTOTPCode(r, user, params.Code)
+	case params.RecoveryCode != "":
+		err = cfg.useRecoveryCode(r, user, params.RecoveryCode)
+	default:
+		respondWithError(w, http.StatusBadRequest, "code or recovery_code is required", nil)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, errInvalidSecondFactor) {
+			respondWithError(w, http.StatusUnauthorized, "invalid code", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not check code", err)
+		return
+	}
+	cfg.recordLoginSuccess(r, accountKey)
+
+	cfg.issueSession(w, r, user)
+}
+
+var errInvalidSecondFactor = errors.New("invalid second factor")
+
+// useTOTPCode accepts a code only if it is newer than the last one used, so
+// a code seen over someone's shoulder can't be replayed.
+func (cfg *apiConfig) useTOTPCode(r *http.Request, user database.User, code string) error {
+	step, err := auth.ValidateTOTP(user.TotpSecret.String, code, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidSecondFactor, err)
+	}
+
+	used, err := cfg.db.UseTotpStep(r.Context(), database.UseTotpStepParams{
+		ID:           user.ID,
+		TotpLastStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return fmt.Errorf("%w: code already used", errInvalidSecondFactor)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) useRecoveryCode(r *http.Request, user database.User, code string) error {
+	used, err := cfg.db.UseRecoveryCode(r.Context(), database.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashRecoveryCode(code),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return fmt.Errorf("%w: unknown or used recovery code", errInvalidSecondFactor)
+	}
+
+	remaining, err := cfg.db.CountUnusedRecoveryCodes(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	cfg.recordSecurityEvent(r.Context(), user.ID, securityEventRecoveryCodeUsed,
+		fmt.Sprintf("recovery code used to log in; %d left", remaining))
+	return nil
+}
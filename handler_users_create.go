@@ -2,12 +2,18 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"regexp"
 	"time"
+	"unicode/utf8"
 
 	"github.com/brettlazarine/Chirpy/internal/auth"
 	"github.com/brettlazarine/Chirpy/internal/database"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type User struct {
@@ -15,13 +21,38 @@ type User struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Location    string    `json:"location"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+func userFromDatabase(user database.User) User {
+	return User{
+		Id:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		Username:      user.Username.String,
+		DisplayName:   user.DisplayName,
+		Bio:           user.Bio,
+		Location:      user.Location,
+		IsChirpyRed:   user.IsChirpyRed,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+	}
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string `json:"password"`
+		Email       string `json:"email"`
+		Username    string `json:"username"`
+		DisplayName string `json:"display_name"`
+		Bio         string `json:"bio"`
+		Location    string `json:"location"`
 	}
 	type response struct {
 		User
@@ -35,7 +66,24 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	// Username is optional at sign-up, but once given it must be valid.
+	var username *string
+	if params.Username != "" {
+		username = &params.Username
+	}
+	err = validateProfile(username, &params.DisplayName, &params.Bio, &params.Location)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	err = cfg.passwordPolicy.Check(params.Password, params.Email, params.Username)
+	if err != nil {
+		respondWithPasswordPolicyError(w, err)
+		return
+	}
+
+	hashedPassword, err := cfg.passwordHasher.Hash(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not hash password", err)
 		return
@@ -44,19 +92,93 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
+		Username:       nullString(username),
+		DisplayName:    params.DisplayName,
+		Bio:            params.Bio,
+		Location:       params.Location,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			respondWithError(w, http.StatusConflict, "email or username already taken", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "could not create user", err)
 		return
 	}
 
+	// The account works without a verified email unless the server requires
+	// one, so a mail failure shouldn't fail sign-up; the user can ask again.
+	err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
+	if err != nil {
+		log.Printf("could not send verification email to user %s: %v", user.ID, err)
+	}
+
 	respondWithJSON(w, http.StatusCreated, response{
-		User: User{
-			Id:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: false,
-		},
+		User: userFromDatabase(user),
 	})
 }
+
+// respondWithPasswordPolicyError explains every way a new password falls short
+// of the policy.
+func respondWithPasswordPolicyError(w http.ResponseWriter, err error) {
+	type response struct {
+		Error    string                 `json:"error"`
+		Problems []auth.PasswordProblem `json:"problems"`
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		respondWithError(w, http.StatusInternalServerError, "could not check password", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusBadRequest, response{
+		Error:    "password does not meet requirements",
+		Problems: policyErr.Problems,
+	})
+}
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+)
+
+// usernameRegexp also keeps usernames from colliding with the /api/users/me
+// routes, since "me" is too short to be one.
+var usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]{3,20}$`)
+
+func validateUsername(username string) error {
+	if !usernameRegexp.MatchString(username) {
+		return errors.New("username must be 3-20 letters, digits or underscores")
+	}
+	return nil
+}
+
+// validateProfile checks the profile fields that are being set; nil fields
+// are left alone and not checked.
+func validateProfile(username, displayName, bio, location *string) error {
+	if username != nil {
+		err := validateUsername(*username)
+		if err != nil {
+			return err
+		}
+	}
+	if displayName != nil && utf8.RuneCountInString(*displayName) > maxDisplayNameLength {
+		return fmt.Errorf("display name must be at most %d characters", maxDisplayNameLength)
+	}
+	if bio != nil && utf8.RuneCountInString(*bio) > maxBioLength {
+		return fmt.Errorf("bio must be at most %d characters", maxBioLength)
+	}
+	if location != nil && utf8.RuneCountInString(*location) > maxLocationLength {
+		return fmt.Errorf("location must be at most %d characters", maxLocationLength)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate
+// value for a unique column.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handlerDeleteUser schedules the caller's account for deletion. It takes the
+// password again, so a leaked access token can't delete an account. The
+// account disappears from view at once and is purged after the grace period
+// unless the user logs in and cancels.
+func (cfg *apiConfig) handlerDeleteUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+	type response struct {
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+	err = cfg.passwordHasher.Check(params.Password, user.HashedPassword)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "incorrect password", err)
+		return
+	}
+
+	user, err = cfg.db.RequestUserDeletion(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "account is already scheduled for deletion", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not delete account", err)
+		return
+	}
+
+	revokedSessions, err := cfg.db.RevokeUserRefreshTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke sessions", err)
+		return
+	}
+	revokedTokens, err := cfg.db.RevokeUserApiTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not revoke personal access tokens", err)
+		return
+	}
+
+	scheduledAt := cfg.deletionScheduledAt(user)
+	cfg.recordSecurityEvent(r.Context(), userID, securityEventDeletionRequested,
+		fmt.Sprintf("account deletion scheduled for %s; revoked %d refresh token(s) and %d personal access token(s)",
+			scheduledAt.Format(time.RFC3339), revokedSessions, revokedTokens))
+
+	respondWithJSON(w, http.StatusAccepted, response{
+		DeletionScheduledAt: scheduledAt,
+	})
+}
+
+// handlerCancelDeletion restores an account during its grace period. Its
+// chirps reappear, but revoked sessions and personal access tokens stay
+// revoked.
+func (cfg *apiConfig) handlerCancelDeletion(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		User
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	cancelled, err := cfg.db.CancelUserDeletion(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not cancel deletion", err)
+		return
+	}
+	if cancelled == 0 {
+		respondWithError(w, http.StatusConflict, "account is not scheduled for deletion", nil)
+		return
+	}
+	cfg.recordSecurityEvent(r.Context(), userID, securityEventDeletionCancelled, "account deletion cancelled")
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{
+		User: userFromDatabase(user),
+	})
+}
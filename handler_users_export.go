@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	dataExportPending = "pending"
+	dataExportReady   = "ready"
+	dataExportFailed  = "failed"
+)
+
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+func dataExportFromDatabase(export database.DataExport) DataExport {
+	dataExport := DataExport{
+		ID:        export.ID,
+		Status:    export.Status,
+		CreatedAt: export.CreatedAt,
+		ExpiresAt: export.ExpiresAt,
+	}
+	if export.CompletedAt.Valid {
+		dataExport.CompletedAt = &export.CompletedAt.Time
+	}
+	return dataExport
+}
+
+// failStaleDataExports marks the user's pending exports that have outlived
+// the build timeout as failed. Such an export was lost, e.g. to a restart,
+// and is replaced like any other failed one. The age is worked out by the
+// database, whose clock wrote created_at.
+func (cfg *apiConfig) failStaleDataExports(r *http.Request, userID uuid.UUID) error {
+	return cfg.db.FailStaleDataExports(r.Context(), database.FailStaleDataExportsParams{
+		UserID:         userID,
+		TimeoutSeconds: dataExportTimeout.Seconds(),
+	})
+}
+
+// handlerCreateDataExport starts building an archive of the caller's data.
+// Only one export is kept per user: asking again while one is being built
+// returns that one, and otherwise replaces the previous export.
+func (cfg *apiConfig) handlerCreateDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	err = cfg.failStaleDataExports(r, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not check data exports", err)
+		return
+	}
+	export, err := cfg.db.GetPendingDataExport(r.Context(), userID)
+	if err == nil {
+		respondWithJSON(w, http.StatusAccepted, dataExportFromDatabase(export))
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "could not get data export", err)
+		return
+	}
+
+	err = cfg.db.DeleteUserDataExports(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete old data exports", err)
+		return
+	}
+	export, err = cfg.db.CreateDataExport(r.Context(), database.CreateDataExportParams{
+		UserID:     userID,
+		TtlSeconds: dataExportTTL.Seconds(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create data export", err)
+		return
+	}
+
+	go cfg.buildDataExport(export)
+
+	respondWithJSON(w, http.StatusAccepted, dataExportFromDatabase(export))
+}
+
+// handlerGetDataExport downloads a finished export as a ZIP. Until then it
+// answers 202 with the export's status, so clients can poll the same URL; a
+// failed export is answered with its status too.
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, r *http.Request) {
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid export ID format", err)
+		return
+	}
+
+	userID, err := cfg.authenticate(r, "")
+	if err != nil {
+		respondWithAuthError(w, err)
+		return
+	}
+
+	err = cfg.failStaleDataExports(r, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not check data exports", err)
+		return
+	}
+	export, err := cfg.db.GetDataExport(r.Context(), database.GetDataExportParams{
+		ID:     exportID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "data export not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get data export", err)
+		return
+	}
+
+	dataExport := dataExportFromDatabase(export)
+	switch dataExport.Status {
+	case dataExportPending:
+		respondWithJSON(w, http.StatusAccepted, dataExport)
+	case dataExportReady:
+		filename := fmt.Sprintf("chirpy-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Content-Length", strconv.Itoa(len(export.Archive)))
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write(export.Archive)
+	default:
+		// A failed export isn't an error in this request; the status tells
+		// the client to ask for a new one.
+		respondWithJSON(w, http.StatusOK, dataExport)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublicProfile is what anyone can see about a user. It must never include
+// the email address.
+type PublicProfile struct {
+	Id             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Username       string    `json:"username"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+
+	profile, err := cfg.db.GetPublicProfile(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, PublicProfile{
+		Id:             profile.ID,
+		CreatedAt:      profile.CreatedAt,
+		Username:       profile.Username.String,
+		DisplayName:    profile.DisplayName,
+		Bio:            profile.Bio,
+		Location:       profile.Location,
+		IsChirpyRed:    profile.IsChirpyRed,
+		ChirpCount:     profile.ChirpCount,
+		FollowerCount:  profile.FollowerCount,
+		FollowingCount: profile.FollowingCount,
+	})
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/brettlazarine/Chirpy/internal/auth"
@@ -10,22 +14,20 @@ import (
 
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    *string `json:"password"`
+		Email       *string `json:"email"`
+		Username    *string `json:"username"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
 	}
 	type response struct {
 		User
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, err := cfg.authenticate(r, auth.ScopeProfileWrite)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "could not get token", err)
-		return
-	}
-
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid token", err)
+		respondWithAuthError(w, err)
 		return
 	}
 
@@ -37,29 +39,139 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
+	err = validateProfile(params.Username, params.DisplayName, params.Bio, params.Location)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not hash password", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userID,
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
-	})
+	if params.Email != nil && *params.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "email cannot be empty", nil)
+		return
+	}
+
+	// Personal access tokens can edit the profile but never the credentials
+	// that log in to the account.
+	viaAPIToken := isAPITokenRequest(r)
+	if viaAPIToken && (params.Email != nil || params.Password != nil) {
+		respondWithError(w, http.StatusForbidden, "personal access tokens cannot change email or password", nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+		respondWithError(w, http.StatusInternalServerError, "could not get user", err)
 		return
 	}
 
+	if params.Email != nil || params.Password != nil {
+		user, err = cfg.updateCredentials(r, user, params.Email, params.Password)
+		if err != nil {
+			var policyErr *auth.PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				respondWithPasswordPolicyError(w, err)
+				return
+			}
+			if isUniqueViolation(err) {
+				respondWithError(w, http.StatusConflict, "email already taken", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+			return
+		}
+	}
+
+	// Profile fields left out of the request keep their current values.
+	if params.Username != nil || params.DisplayName != nil || params.Bio != nil || params.Location != nil {
+		user, err = cfg.db.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+			ID:          userID,
+			Username:    nullString(params.Username),
+			DisplayName: nullString(params.DisplayName),
+			Bio:         nullString(params.Bio),
+			Location:    nullString(params.Location),
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				respondWithError(w, http.StatusConflict, "username already taken", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+			return
+		}
+	}
+
 	respondWithJSON(w, http.StatusOK, response{
-		User{
-			Id:          user.ID,
-			Email:       user.Email,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User: userFromDatabase(user),
+	})
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// updateCredentials sets the user's email and password; a nil field keeps its
+// current value. Resending the current password keeps the existing hash and
+// sessions; a new password must pass the password policy, logs out every
+// other session and revokes every personal access token. A new email has to
+// be verified again.
+func (cfg *apiConfig) updateCredentials(r *http.Request, user database.User, email, password *string) (database.User, error) {
+	oldEmail := user.Email
+	newEmail := user.Email
+	if email != nil {
+		newEmail = *email
+	}
+
+	hashedPassword := user.HashedPassword
+	passwordChanged := password != nil && cfg.passwordHasher.Check(*password, user.HashedPassword) != nil
+	if passwordChanged {
+		err := cfg.passwordPolicy.Check(*password, newEmail, user.Username.String)
+		if err != nil {
+			return database.User{}, err
+		}
+		hashedPassword, err = cfg.passwordHasher.Hash(*password)
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+
+	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
+		ID:             user.ID,
+		Email:          newEmail,
+		HashedPassword: hashedPassword,
 	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if user.Email != oldEmail {
+		err = cfg.sendVerificationEmail(r.Context(), user.ID, user.Email)
+		if err != nil {
+			log.Printf("could not send verification email to user %s: %v", user.ID, err)
+		}
+	}
+
+	if passwordChanged {
+		// Anyone holding an old refresh token has to log in with the new
+		// password, except the session making the change. Personal access
+		// tokens could have been made with the old password, so they go too.
+		revokedSessions, err := cfg.db.RevokeOtherRefreshTokens(r.Context(), database.RevokeOtherRefreshTokensParams{
+			UserID:   user.ID,
+			FamilyID: cfg.currentSessionID(r),
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+		revokedTokens, err := cfg.db.RevokeUserApiTokens(r.Context(), user.ID)
+		if err != nil {
+			return database.User{}, err
+		}
+		cfg.recordSecurityEvent(r.Context(), user.ID, securityEventPasswordChanged,
+			fmt.Sprintf("password changed; revoked %d refresh token(s) and %d personal access token(s)",
+				revokedSessions, revokedTokens))
+	}
+
+	return user, nil
 }
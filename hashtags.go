@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxHashtagLength = 50
+
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+// extractHashtags returns the distinct, lower-cased tags in a chirp body in
+// the order they first appear.
+func extractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(body, -1) {
+		tag := normalizeHashtag(match[1])
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// normalizeHashtag lower-cases a tag and strips a leading '#', returning ""
+// for anything that can't be a tag.
+func normalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || len(tag) > maxHashtagLength {
+		return ""
+	}
+	return tag
+}
+
+// syncChirpHashtags makes the chirp's stored tags match the tags in body.
+func syncChirpHashtags(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	err := q.UntagChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+
+	tags := extractHashtags(body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	err = q.CreateHashtags(ctx, tags)
+	if err != nil {
+		return err
+	}
+
+	return q.TagChirp(ctx, database.TagChirpParams{
+		ChirpID: chirpID,
+		Names:   tags,
+	})
+}
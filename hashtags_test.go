@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No tags",
+			body: "just a chirp",
+			want: []string{},
+		},
+		{
+			name: "Tags in order of appearance",
+			body: "#golang is better than #rust",
+			want: []string{"golang", "rust"},
+		},
+		{
+			name: "Trailing punctuation",
+			body: "loving #go! and #sql, also #chirpy.",
+			want: []string{"go", "sql", "chirpy"},
+		},
+		{
+			name: "Wrapped in brackets",
+			body: "(#go) [#sql]",
+			want: []string{"go", "sql"},
+		},
+		{
+			name: "Case folded",
+			body: "#GoLang",
+			want: []string{"golang"},
+		},
+		{
+			name: "Duplicates differing in case",
+			body: "#go #Go #GO",
+			want: []string{"go"},
+		},
+		{
+			name: "Unicode letters",
+			body: "#café #日本 #ÉTÉ",
+			want: []string{"café", "日本", "été"},
+		},
+		{
+			name: "Digits and underscores",
+			body: "#web_3 #2024",
+			want: []string{"web_3", "2024"},
+		},
+		{
+			name: "Inside a word",
+			body: "issue#42 and c#",
+			want: []string{},
+		},
+		{
+			name: "HTML entity",
+			body: "it&#39;s",
+			want: []string{},
+		},
+		{
+			name: "Bare hash",
+			body: "# heading",
+			want: []string{},
+		},
+		{
+			name: "Doubled hash",
+			body: "##go",
+			want: []string{"go"},
+		},
+		{
+			name: "Too long",
+			body: "#" + strings.Repeat("a", maxHashtagLength+1) + " #ok",
+			want: []string{"ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractHashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractHashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{
+			name: "Leading hash",
+			tag:  "#Go",
+			want: "go",
+		},
+		{
+			name: "No hash",
+			tag:  "Trending",
+			want: "trending",
+		},
+		{
+			name: "Unicode",
+			tag:  "#ÉTÉ",
+			want: "été",
+		},
+		{
+			name: "Empty",
+			tag:  "#",
+			want: "",
+		},
+		{
+			name: "Longest allowed",
+			tag:  strings.Repeat("a", maxHashtagLength),
+			want: strings.Repeat("a", maxHashtagLength),
+		},
+		{
+			name: "Too long",
+			tag:  strings.Repeat("a", maxHashtagLength+1),
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeHashtag(tt.tag)
+			if got != tt.want {
+				t.Errorf("normalizeHashtag() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMakeAPIToken(t *testing.T) {
+	token, err := MakeAPIToken()
+	if err != nil {
+		t.Fatalf("MakeAPIToken() error = %v", err)
+	}
+
+	if !IsAPIToken(token) {
+		t.Errorf("MakeAPIToken() = %v, want prefix %v", token, APITokenPrefix)
+	}
+	if len(token) != len(APITokenPrefix)+64 {
+		t.Errorf("MakeAPIToken() length = %v, want %v", len(token), len(APITokenPrefix)+64)
+	}
+	if HashAPIToken(token) == token {
+		t.Errorf("HashAPIToken() returned the token unchanged")
+	}
+}
+
+func TestIsAPIToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{
+			name:  "Personal access token",
+			token: "chirpy_pat_0123abcd",
+			want:  true,
+		},
+		{
+			name:  "JWT",
+			token: "eyJhbGciOiJIUzI1NiJ9.e30.signature",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAPIToken(tt.token); got != tt.want {
+				t.Errorf("IsAPIToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantScopes []string
+		wantErr    bool
+	}{
+		{
+			name:       "Valid scopes",
+			scopes:     []string{ScopeChirpsWrite, ScopeChirpsRead},
+			wantScopes: []string{ScopeChirpsRead, ScopeChirpsWrite},
+			wantErr:    false,
+		},
+		{
+			name:       "Duplicate scopes",
+			scopes:     []string{ScopeProfileWrite, ScopeProfileWrite},
+			wantScopes: []string{ScopeProfileWrite},
+			wantErr:    false,
+		},
+		{
+			name:    "Unknown scope",
+			scopes:  []string{ScopeChirpsRead, "admin"},
+			wantErr: true,
+		},
+		{
+			name:    "No scopes",
+			scopes:  nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotScopes, err := ValidateScopes(tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScopes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !slices.Equal(gotScopes, tt.wantScopes) {
+				t.Errorf("ValidateScopes() = %v, want %v", gotScopes, tt.wantScopes)
+			}
+		})
+	}
+}
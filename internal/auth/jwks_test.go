@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func testPrivateKeyPEM(t *testing.T, privateKey interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func testAsymmetricKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	keyring, err := NewKeyring("ed", []SigningKey{
+		{ID: "hmac", Secret: "secret"},
+		{ID: "ed", Algorithm: "EdDSA", PrivateKey: testPrivateKeyPEM(t, edKey)},
+		{ID: "rsa", Algorithm: "RS256", PrivateKey: testPrivateKeyPEM(t, rsaKey)},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return keyring
+}
+
+func TestValidateJWT_Asymmetric(t *testing.T) {
+	userID := uuid.New()
+	keyring := testAsymmetricKeyring(t)
+
+	for _, activeID := range []string{"hmac", "ed", "rsa"} {
+		t.Run(activeID, func(t *testing.T) {
+			keyring.mu.Lock()
+			keyring.activeID = activeID
+			keyring.mu.Unlock()
+
+			tokenString, err := MakeSessionJWT(userID, uuid.New(), keyring, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeSessionJWT() error = %v", err)
+			}
+			gotUserID, err := ValidateJWT(tokenString, keyring, 0)
+			if err != nil {
+				t.Fatalf("ValidateJWT() error = %v", err)
+			}
+			if gotUserID != userID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+			}
+		})
+	}
+}
+
+func TestValidateJWT_AlgorithmMismatch(t *testing.T) {
+	keyring := testAsymmetricKeyring(t)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   uuid.New().String(),
+	})
+	token.Header["kid"] = "ed"
+	tokenString, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	_, err = ValidateJWT(tokenString, keyring, 0)
+	if err == nil {
+		t.Errorf("ValidateJWT() error = nil, want error for HS256 token naming an EdDSA key")
+	}
+}
+
+func TestNewKeyring_AsymmetricErrors(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  SigningKey
+	}{
+		{
+			name: "Wrong key type for algorithm",
+			key:  SigningKey{ID: "k", Algorithm: "RS256", PrivateKey: testPrivateKeyPEM(t, edKey)},
+		},
+		{
+			name: "RSA key too small",
+			key:  SigningKey{ID: "k", Algorithm: "RS256", PrivateKey: testPrivateKeyPEM(t, smallRSAKey)},
+		},
+		{
+			name: "Missing private key",
+			key:  SigningKey{ID: "k", Algorithm: "EdDSA"},
+		},
+		{
+			name: "Unsupported algorithm",
+			key:  SigningKey{ID: "k", Algorithm: "ES256", PrivateKey: testPrivateKeyPEM(t, edKey)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring("k", []SigningKey{tt.key})
+			if err == nil {
+				t.Errorf("NewKeyring() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	userID := uuid.New()
+	keyring := testAsymmetricKeyring(t)
+
+	jwks := keyring.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2 (no HMAC keys)", len(jwks.Keys))
+	}
+
+	for _, jwk := range jwks.Keys {
+		t.Run(jwk.KeyID, func(t *testing.T) {
+			keyring.mu.Lock()
+			keyring.activeID = jwk.KeyID
+			keyring.mu.Unlock()
+
+			tokenString, err := MakeSessionJWT(userID, uuid.New(), keyring, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeSessionJWT() error = %v", err)
+			}
+
+			// Verify the way a downstream service would: only with the JWK.
+			var publicKey interface{}
+			switch jwk.KeyType {
+			case "OKP":
+				x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+				if err != nil {
+					t.Fatalf("decoding x error = %v", err)
+				}
+				publicKey = ed25519.PublicKey(x)
+			case "RSA":
+				n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+				if err != nil {
+					t.Fatalf("decoding n error = %v", err)
+				}
+				e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+				if err != nil {
+					t.Fatalf("decoding e error = %v", err)
+				}
+				publicKey = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+			default:
+				t.Fatalf("unexpected key type %q", jwk.KeyType)
+			}
+
+			_, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				return publicKey, nil
+			}, jwt.WithValidMethods([]string{jwk.Algorithm}))
+			if err != nil {
+				t.Errorf("verifying with JWK error = %v", err)
+			}
+		})
+	}
+}
@@ -15,21 +15,70 @@ type TokenType string
 
 const (
 	TokenTypeAccess TokenType = "chirpy-access"
+	// TokenTypeMFAChallenge proves the password was right and is traded,
+	// with a second factor, for an access token. It is never accepted as one.
+	TokenTypeMFAChallenge TokenType = "chirpy-mfa-challenge"
+	// TokenTypeEmailVerification is mailed to a user to prove they own their
+	// address. Its jti names the server-side record that makes it single-use.
+	TokenTypeEmailVerification TokenType = "chirpy-email-verification"
 )
 
+var supportedAlgorithms = []string{
+	jwt.SigningMethodHS256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+	jwt.SigningMethodRS256.Alg(),
+}
+
 var ErrNoAuthHeadIncluded = errors.New("no Authorization header included in request")
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	signingKey := []byte(tokenSecret)
-	claims := &jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour * 1)),
-		Subject:   userID.String(),
+type claims struct {
+	jwt.RegisteredClaims
+	// SessionID is the refresh token family an access token was issued to.
+	SessionID string `json:"sid,omitempty"`
+}
+
+// MakeSessionJWT signs an access token for userID with the keyring's active
+// key, names that key in the kid header, and names the session the token was
+// issued to, so the session can be told apart from the user's others.
+func MakeSessionJWT(userID, sessionID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	c := newClaims(userID, expiresIn, TokenTypeAccess)
+	c.SessionID = sessionID.String()
+	return signClaims(c, keyring)
+}
+
+func MakeMFAChallengeJWT(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	return makeJWT(userID, keyring, expiresIn, TokenTypeMFAChallenge)
+}
+
+// MakeEmailVerificationJWT signs a token for verifying userID's email.
+// verificationID is stored as the token's jti.
+func MakeEmailVerificationJWT(userID, verificationID uuid.UUID, keyring *Keyring, expiresIn time.Duration) (string, error) {
+	c := newClaims(userID, expiresIn, TokenTypeEmailVerification)
+	c.ID = verificationID.String()
+	return signClaims(c, keyring)
+}
+
+func makeJWT(userID uuid.UUID, keyring *Keyring, expiresIn time.Duration, tokenType TokenType) (string, error) {
+	return signClaims(newClaims(userID, expiresIn, tokenType), keyring)
+}
+
+func newClaims(userID uuid.UUID, expiresIn time.Duration, tokenType TokenType) *claims {
+	now := time.Now().UTC()
+	return &claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(tokenType),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+		},
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString(signingKey)
+func signClaims(c *claims, keyring *Keyring) (string, error) {
+	key := keyring.activeKey()
+	token := jwt.NewWithClaims(key.method, c)
+	token.Header["kid"] = key.id
+	ss, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", err
 	}
@@ -37,34 +86,90 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return ss, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, &claimsStruct, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
+// ValidateJWT checks an access token against the key named in its kid header
+// and returns the user it was issued to. leeway is how far past its expiry a
+// token is still accepted, to allow for clock skew between servers.
+func ValidateJWT(tokenString string, keyring *Keyring, leeway time.Duration) (uuid.UUID, error) {
+	return validateJWT(tokenString, keyring, leeway, TokenTypeAccess)
+}
+
+func ValidateMFAChallengeJWT(tokenString string, keyring *Keyring, leeway time.Duration) (uuid.UUID, error) {
+	return validateJWT(tokenString, keyring, leeway, TokenTypeMFAChallenge)
+}
+
+// ValidateEmailVerificationJWT returns the user an email verification token
+// was issued to and the ID of its verification record. Whether the token has
+// already been used is up to the caller to check.
+func ValidateEmailVerificationJWT(tokenString string, keyring *Keyring, leeway time.Duration) (userID, verificationID uuid.UUID, err error) {
+	c, err := parseClaims(tokenString, keyring, leeway, TokenTypeEmailVerification)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	userID, err = uuid.Parse(c.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	verificationID, err = uuid.Parse(c.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid token ID: %w", err)
+	}
+
+	return userID, verificationID, nil
+}
+
+func validateJWT(tokenString string, keyring *Keyring, leeway time.Duration, tokenType TokenType) (uuid.UUID, error) {
+	c, err := parseClaims(tokenString, keyring, leeway, tokenType)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	userIDString, err := token.Claims.GetSubject()
+	userID, err := uuid.Parse(c.Subject)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	issuer, err := token.Claims.GetIssuer()
+	return userID, nil
+}
+
+// SessionIDFromJWT returns the session an access token was issued to, or
+// uuid.Nil for a token that doesn't name one.
+func SessionIDFromJWT(tokenString string, keyring *Keyring, leeway time.Duration) (uuid.UUID, error) {
+	c, err := parseClaims(tokenString, keyring, leeway, TokenTypeAccess)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, fmt.Errorf("invalid token issuer")
+	if c.SessionID == "" {
+		return uuid.Nil, nil
 	}
 
-	userID, err := uuid.Parse(userIDString)
+	return uuid.Parse(c.SessionID)
+}
+
+func parseClaims(tokenString string, keyring *Keyring, leeway time.Duration, tokenType TokenType) (*claims, error) {
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(tokenString, c, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keyring.validationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// A key only accepts its own algorithm, so an RS256 public key can
+		// never be misused as an HMAC secret.
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("signing key %q does not use %s", key.id, token.Method.Alg())
+		}
+		return key.verifyKey, nil
+	}, jwt.WithValidMethods(supportedAlgorithms), jwt.WithLeeway(leeway), jwt.WithExpirationRequired())
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 
-	return userID, nil
+	if c.Issuer != string(tokenType) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+
+	return c, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
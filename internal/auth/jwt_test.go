@@ -6,9 +6,19 @@ import (
 
 	"net/http"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+func testKeyring(t *testing.T, secret string) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyringFromSecret(secret)
+	if err != nil {
+		t.Fatalf("NewKeyringFromSecret() error = %v", err)
+	}
+	return keyring
+}
+
 func TestJWT(t *testing.T) {
 	userID := uuid.New()
 	tokenSecretPass := "secretive"
@@ -24,7 +34,7 @@ func TestJWT(t *testing.T) {
 		wantError   bool
 	}{
 		{
-			name:        "TestMakeJWT",
+			name:        "TestMakeSessionJWT",
 			userID:      userID,
 			tokenSecret: tokenSecretPass,
 			expiresIn:   expiresInHour,
@@ -75,7 +85,7 @@ func TestJWT(t *testing.T) {
 
 	for _, tt := range testPass {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := MakeJWT(tt.userID, tt.tokenSecret, tt.expiresIn)
+			_, err := MakeSessionJWT(tt.userID, uuid.New(), testKeyring(t, tt.tokenSecret), tt.expiresIn)
 			if (err != nil) != tt.wantError {
 				t.Errorf("JWT *%v* error = %v, wantError %v", tt.name, err, tt.wantError)
 			}
@@ -83,8 +93,8 @@ func TestJWT(t *testing.T) {
 	}
 	for _, tt := range testFailSecret {
 		t.Run(tt.name, func(t *testing.T) {
-			token, _ := MakeJWT(tt.userID, tt.tokenSecretOK, tt.expiresIn)
-			_, err := ValidateJWT(token, tt.tokenSecretFail)
+			token, _ := MakeSessionJWT(tt.userID, uuid.New(), testKeyring(t, tt.tokenSecretOK), tt.expiresIn)
+			_, err := ValidateJWT(token, testKeyring(t, tt.tokenSecretFail), 0)
 			if (err != nil) != tt.wantError {
 				t.Errorf("JWT *%v* error = %v, wantError %v", tt.name, err, tt.wantError)
 			}
@@ -92,9 +102,9 @@ func TestJWT(t *testing.T) {
 	}
 	for _, tt := range testFailExpired {
 		t.Run(tt.name, func(t *testing.T) {
-			token, _ := MakeJWT(tt.userID, tt.tokenSecret, tt.expiresIn)
+			token, _ := MakeSessionJWT(tt.userID, uuid.New(), testKeyring(t, tt.tokenSecret), tt.expiresIn)
 			time.Sleep(2 * time.Second)
-			_, err := ValidateJWT(token, tt.tokenSecret)
+			_, err := ValidateJWT(token, testKeyring(t, tt.tokenSecret), 0)
 			if (err != nil) != tt.wantError {
 				t.Errorf("JWT *%v* error = %v, wantError %v", tt.name, err, tt.wantError)
 			}
@@ -104,7 +114,7 @@ func TestJWT(t *testing.T) {
 
 func TestValidateJWT_BootDev(t *testing.T) {
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	validToken, _ := MakeSessionJWT(userID, uuid.New(), testKeyring(t, "secret"), time.Hour)
 
 	tests := []struct {
 		name        string
@@ -138,7 +148,7 @@ func TestValidateJWT_BootDev(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotUserID, err := ValidateJWT(tt.tokenString, testKeyring(t, tt.tokenSecret), 0)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -150,6 +160,104 @@ func TestValidateJWT_BootDev(t *testing.T) {
 	}
 }
 
+func TestMakeSessionJWT_ExpiresIn(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+	}{
+		{
+			name:      "Short-lived mobile token",
+			expiresIn: 15 * time.Minute,
+		},
+		{
+			name:      "Long-lived token",
+			expiresIn: 30 * 24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := MakeSessionJWT(userID, uuid.New(), testKeyring(t, "secret"), tt.expiresIn)
+			if err != nil {
+				t.Fatalf("MakeSessionJWT() error = %v", err)
+			}
+
+			claims := jwt.RegisteredClaims{}
+			_, _, err = jwt.NewParser().ParseUnverified(tokenString, &claims)
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+
+			gotTTL := claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+			if gotTTL != tt.expiresIn {
+				t.Errorf("MakeSessionJWT() lifetime = %v, want %v", gotTTL, tt.expiresIn)
+			}
+		})
+	}
+}
+
+func TestValidateJWT_Lifetimes(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		leeway    time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "Unexpired short-lived token",
+			expiresIn: 15 * time.Minute,
+			leeway:    0,
+			wantErr:   false,
+		},
+		{
+			name:      "Unexpired long-lived token",
+			expiresIn: 60 * 24 * time.Hour,
+			leeway:    0,
+			wantErr:   false,
+		},
+		{
+			name:      "Expired token",
+			expiresIn: -time.Minute,
+			leeway:    0,
+			wantErr:   true,
+		},
+		{
+			name:      "Expired token within leeway",
+			expiresIn: -time.Minute,
+			leeway:    2 * time.Minute,
+			wantErr:   false,
+		},
+		{
+			name:      "Expired token beyond leeway",
+			expiresIn: -time.Hour,
+			leeway:    2 * time.Minute,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenString, err := MakeSessionJWT(userID, uuid.New(), testKeyring(t, "secret"), tt.expiresIn)
+			if err != nil {
+				t.Fatalf("MakeSessionJWT() error = %v", err)
+			}
+
+			gotUserID, err := ValidateJWT(tokenString, testKeyring(t, "secret"), tt.leeway)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && gotUserID != userID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	validToken := "Bearer valid.token.string"
 	tokenString := "valid.token.string"
@@ -235,3 +343,113 @@ func TestGetBearerToken_BootDev(t *testing.T) {
 		})
 	}
 }
+
+func TestMFAChallengeJWT(t *testing.T) {
+	userID := uuid.New()
+	keyring := testKeyring(t, "secret")
+
+	challenge, err := MakeMFAChallengeJWT(userID, keyring, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeMFAChallengeJWT() error = %v", err)
+	}
+	access, err := MakeSessionJWT(userID, uuid.New(), keyring, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	gotUserID, err := ValidateMFAChallengeJWT(challenge, keyring, 0)
+	if err != nil {
+		t.Fatalf("ValidateMFAChallengeJWT() error = %v", err)
+	}
+	if gotUserID != userID {
+		t.Errorf("ValidateMFAChallengeJWT() gotUserID = %v, want %v", gotUserID, userID)
+	}
+
+	_, err = ValidateJWT(challenge, keyring, 0)
+	if err == nil {
+		t.Errorf("ValidateJWT() accepted an MFA challenge token")
+	}
+	_, err = ValidateMFAChallengeJWT(access, keyring, 0)
+	if err == nil {
+		t.Errorf("ValidateMFAChallengeJWT() accepted an access token")
+	}
+}
+
+func TestEmailVerificationJWT(t *testing.T) {
+	userID := uuid.New()
+	verificationID := uuid.New()
+	keyring := testKeyring(t, "secret")
+
+	token, err := MakeEmailVerificationJWT(userID, verificationID, keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeEmailVerificationJWT() error = %v", err)
+	}
+	access, err := MakeSessionJWT(userID, uuid.New(), keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	gotUserID, gotVerificationID, err := ValidateEmailVerificationJWT(token, keyring, 0)
+	if err != nil {
+		t.Fatalf("ValidateEmailVerificationJWT() error = %v", err)
+	}
+	if gotUserID != userID {
+		t.Errorf("ValidateEmailVerificationJWT() gotUserID = %v, want %v", gotUserID, userID)
+	}
+	if gotVerificationID != verificationID {
+		t.Errorf("ValidateEmailVerificationJWT() gotVerificationID = %v, want %v", gotVerificationID, verificationID)
+	}
+
+	_, err = ValidateJWT(token, keyring, 0)
+	if err == nil {
+		t.Errorf("ValidateJWT() accepted an email verification token")
+	}
+	_, _, err = ValidateEmailVerificationJWT(access, keyring, 0)
+	if err == nil {
+		t.Errorf("ValidateEmailVerificationJWT() accepted an access token")
+	}
+	_, _, err = ValidateEmailVerificationJWT(token, testKeyring(t, "other secret"), 0)
+	if err == nil {
+		t.Errorf("ValidateEmailVerificationJWT() accepted a token signed with another key")
+	}
+}
+
+func TestSessionJWT(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	keyring := testKeyring(t, "secret")
+
+	token, err := MakeSessionJWT(userID, sessionID, keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	gotUserID, err := ValidateJWT(token, keyring, 0)
+	if err != nil {
+		t.Fatalf("ValidateJWT() error = %v", err)
+	}
+	if gotUserID != userID {
+		t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+	}
+
+	gotSessionID, err := SessionIDFromJWT(token, keyring, 0)
+	if err != nil {
+		t.Fatalf("SessionIDFromJWT() error = %v", err)
+	}
+	if gotSessionID != sessionID {
+		t.Errorf("SessionIDFromJWT() = %v, want %v", gotSessionID, sessionID)
+	}
+
+	// Tokens issued before sessions were tracked carry no sid.
+	sessionless, err := signClaims(newClaims(userID, time.Hour, TokenTypeAccess), keyring)
+	if err != nil {
+		t.Fatalf("signClaims() error = %v", err)
+	}
+	gotSessionID, err = SessionIDFromJWT(sessionless, keyring, 0)
+	if err != nil {
+		t.Fatalf("SessionIDFromJWT() error = %v", err)
+	}
+	if gotSessionID != uuid.Nil {
+		t.Errorf("SessionIDFromJWT() = %v, want uuid.Nil for a token without a session", gotSessionID)
+	}
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultKeyID is the id of a keyring built from a single secret. Tokens
+// signed before keys had ids carry no kid header and are checked against the
+// key with this id, so a JWT_SECRET can be moved into a keyring file as-is.
+const DefaultKeyID = "default"
+
+// SigningKey is one JWT signing key as it appears in a keyring file.
+// Algorithm is HS256 (the default), EdDSA or RS256. HS256 keys use Secret;
+// the asymmetric ones take a PEM private key, inline or from a file, whose
+// public half is published in the JWKS. A retired key no longer validates
+// tokens; it is kept in the file only as a record until it is removed.
+type SigningKey struct {
+	ID             string `json:"id"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret"`
+	PrivateKey     string `json:"private_key"`
+	PrivateKeyFile string `json:"private_key_file"`
+	Retired        bool   `json:"retired"`
+}
+
+// keyringKey is a SigningKey parsed into what golang-jwt needs.
+type keyringKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	retired   bool
+}
+
+// Keyring holds the keys used to sign and validate JWTs. New tokens are
+// signed with the active key, and a token is accepted if the key named in its
+// kid header is still in the keyring and not retired.
+//
+// A keyring loaded from a file can be reloaded in place, so rotating a key is:
+// add the new key, reload everywhere, make it active, reload, and retire the
+// old key once the tokens it signed have expired.
+type Keyring struct {
+	path string
+
+	mu       sync.RWMutex
+	activeID string
+	keys     map[string]keyringKey
+}
+
+type keyringFile struct {
+	ActiveKey string       `json:"active_key"`
+	Keys      []SigningKey `json:"keys"`
+}
+
+// NewKeyring builds a keyring that signs with the key whose id is activeID.
+func NewKeyring(activeID string, keys []SigningKey) (*Keyring, error) {
+	kr := &Keyring{}
+	err := kr.set(activeID, keys)
+	if err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// NewKeyringFromSecret builds a keyring holding just secret under
+// DefaultKeyID.
+func NewKeyringFromSecret(secret string) (*Keyring, error) {
+	return NewKeyring(DefaultKeyID, []SigningKey{{ID: DefaultKeyID, Secret: secret}})
+}
+
+// LoadKeyring reads a keyring from a JSON file of the form
+//
+//	{"active_key": "2025-01", "keys": [{"id": "2025-01", "secret": "..."}]}
+func LoadKeyring(path string) (*Keyring, error) {
+	kr := &Keyring{path: path}
+	err := kr.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Reload rereads the file the keyring was loaded from. If the file is
+// invalid the keyring keeps its current keys.
+func (kr *Keyring) Reload() error {
+	if kr.path == "" {
+		return errors.New("keyring was not loaded from a file")
+	}
+
+	data, err := os.ReadFile(kr.path)
+	if err != nil {
+		return err
+	}
+
+	var file keyringFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return fmt.Errorf("could not parse keyring %s: %w", kr.path, err)
+	}
+
+	return kr.set(file.ActiveKey, file.Keys)
+}
+
+func (kr *Keyring) set(activeID string, keys []SigningKey) error {
+	byID := make(map[string]keyringKey, len(keys))
+	for _, key := range keys {
+		if key.ID == "" {
+			return errors.New("signing key has no id")
+		}
+		if _, ok := byID[key.ID]; ok {
+			return fmt.Errorf("signing key %q is listed twice", key.ID)
+		}
+		parsed, err := kr.parseKey(key)
+		if err != nil {
+			return fmt.Errorf("signing key %q: %w", key.ID, err)
+		}
+		byID[key.ID] = parsed
+	}
+
+	active, ok := byID[activeID]
+	if !ok {
+		return fmt.Errorf("active signing key %q is not in the keyring", activeID)
+	}
+	if active.retired {
+		return fmt.Errorf("active signing key %q is retired", activeID)
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.activeID = activeID
+	kr.keys = byID
+	return nil
+}
+
+func (kr *Keyring) parseKey(key SigningKey) (keyringKey, error) {
+	parsed := keyringKey{
+		id:      key.ID,
+		retired: key.Retired,
+	}
+
+	if key.Algorithm == "" || key.Algorithm == jwt.SigningMethodHS256.Alg() {
+		if key.Secret == "" {
+			return keyringKey{}, errors.New("no secret")
+		}
+		parsed.method = jwt.SigningMethodHS256
+		parsed.signKey = []byte(key.Secret)
+		parsed.verifyKey = []byte(key.Secret)
+		return parsed, nil
+	}
+
+	pemData := []byte(key.PrivateKey)
+	if key.PrivateKeyFile != "" {
+		path := key.PrivateKeyFile
+		if !filepath.IsAbs(path) && kr.path != "" {
+			path = filepath.Join(filepath.Dir(kr.path), path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return keyringKey{}, err
+		}
+		pemData = data
+	}
+	privateKey, err := parsePrivateKey(pemData)
+	if err != nil {
+		return keyringKey{}, err
+	}
+
+	switch key.Algorithm {
+	case jwt.SigningMethodEdDSA.Alg():
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return keyringKey{}, errors.New("EdDSA needs an Ed25519 private key")
+		}
+		parsed.method = jwt.SigningMethodEdDSA
+		parsed.signKey = edKey
+		parsed.verifyKey = edKey.Public()
+	case jwt.SigningMethodRS256.Alg():
+		rsaKey, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return keyringKey{}, errors.New("RS256 needs an RSA private key")
+		}
+		if rsaKey.N.BitLen() < minRSAKeyBits {
+			return keyringKey{}, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		parsed.method = jwt.SigningMethodRS256
+		parsed.signKey = rsaKey
+		parsed.verifyKey = &rsaKey.PublicKey
+	default:
+		return keyringKey{}, fmt.Errorf("unsupported algorithm %q", key.Algorithm)
+	}
+
+	return parsed, nil
+}
+
+const minRSAKeyBits = 2048
+
+// parsePrivateKey reads a PKCS #8 private key, or a PKCS #1 RSA key as
+// written by older openssl versions.
+func parsePrivateKey(pemData []byte) (interface{}, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM private key")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func (kr *Keyring) activeKey() keyringKey {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.keys[kr.activeID]
+}
+
+// validationKey returns the key a token with the given kid must be signed
+// with.
+func (kr *Keyring) validationKey(kid string) (keyringKey, error) {
+	if kid == "" {
+		kid = DefaultKeyID
+	}
+
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	key, ok := kr.keys[kid]
+	if !ok {
+		return keyringKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.retired {
+		return keyringKey{}, fmt.Errorf("signing key %q is retired", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name     string
+		activeID string
+		keys     []SigningKey
+		wantErr  bool
+	}{
+		{
+			name:     "Valid keyring",
+			activeID: "new",
+			keys:     []SigningKey{{ID: "old", Secret: "old-secret"}, {ID: "new", Secret: "new-secret"}},
+			wantErr:  false,
+		},
+		{
+			name:     "Missing active key",
+			activeID: "missing",
+			keys:     []SigningKey{{ID: "old", Secret: "old-secret"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Retired active key",
+			activeID: "old",
+			keys:     []SigningKey{{ID: "old", Secret: "old-secret", Retired: true}},
+			wantErr:  true,
+		},
+		{
+			name:     "Duplicate key id",
+			activeID: "old",
+			keys:     []SigningKey{{ID: "old", Secret: "a"}, {ID: "old", Secret: "b"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Empty secret",
+			activeID: "old",
+			keys:     []SigningKey{{ID: "old", Secret: ""}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.activeID, tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMakeSessionJWT_Kid(t *testing.T) {
+	keyring, err := NewKeyring("new", []SigningKey{{ID: "old", Secret: "old-secret"}, {ID: "new", Secret: "new-secret"}})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+
+	tokenString, err := MakeSessionJWT(uuid.New(), uuid.New(), keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	if token.Header["kid"] != "new" {
+		t.Errorf("MakeSessionJWT() kid = %v, want %v", token.Header["kid"], "new")
+	}
+}
+
+func TestValidateJWT_Rotation(t *testing.T) {
+	userID := uuid.New()
+
+	oldKeyring, err := NewKeyring("old", []SigningKey{{ID: "old", Secret: "old-secret"}})
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	oldToken, err := MakeSessionJWT(userID, uuid.New(), oldKeyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	}).SignedString([]byte("legacy-secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		tokenString string
+		keys        []SigningKey
+		wantErr     bool
+	}{
+		{
+			name:        "Token signed with previous active key",
+			tokenString: oldToken,
+			keys:        []SigningKey{{ID: "old", Secret: "old-secret"}, {ID: "new", Secret: "new-secret"}},
+			wantErr:     false,
+		},
+		{
+			name:        "Token signed with retired key",
+			tokenString: oldToken,
+			keys:        []SigningKey{{ID: "old", Secret: "old-secret", Retired: true}, {ID: "new", Secret: "new-secret"}},
+			wantErr:     true,
+		},
+		{
+			name:        "Token signed with removed key",
+			tokenString: oldToken,
+			keys:        []SigningKey{{ID: "new", Secret: "new-secret"}},
+			wantErr:     true,
+		},
+		{
+			name:        "Token with kid of a different key",
+			tokenString: oldToken,
+			keys:        []SigningKey{{ID: "old", Secret: "new-secret"}, {ID: "new", Secret: "new-secret"}},
+			wantErr:     true,
+		},
+		{
+			name:        "Token without kid checked against default key",
+			tokenString: legacyToken,
+			keys:        []SigningKey{{ID: DefaultKeyID, Secret: "legacy-secret"}, {ID: "new", Secret: "new-secret"}},
+			wantErr:     false,
+		},
+		{
+			name:        "Token without kid and no default key",
+			tokenString: legacyToken,
+			keys:        []SigningKey{{ID: "new", Secret: "legacy-secret"}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring("new", tt.keys)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+
+			gotUserID, err := ValidateJWT(tt.tokenString, keyring, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && gotUserID != userID {
+				t.Errorf("ValidateJWT() gotUserID = %v, want %v", gotUserID, userID)
+			}
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	writeKeyring := func(contents string) {
+		t.Helper()
+		err := os.WriteFile(path, []byte(contents), 0o600)
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	writeKeyring(`{"active_key": "k1", "keys": [{"id": "k1", "secret": "one"}]}`)
+	keyring, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+	token, err := MakeSessionJWT(uuid.New(), uuid.New(), keyring, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeSessionJWT() error = %v", err)
+	}
+
+	writeKeyring(`{"active_key": "k2", "keys": [{"id": "k1", "secret": "one"}, {"id": "k2", "secret": "two"}]}`)
+	err = keyring.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := keyring.activeKey().id; got != "k2" {
+		t.Errorf("Reload() active key = %v, want %v", got, "k2")
+	}
+	_, err = ValidateJWT(token, keyring, 0)
+	if err != nil {
+		t.Errorf("ValidateJWT() after rotation error = %v", err)
+	}
+
+	writeKeyring(`{"active_key": "k3", "keys": []}`)
+	err = keyring.Reload()
+	if err == nil {
+		t.Errorf("Reload() with invalid keyring error = nil, want error")
+	}
+	if got := keyring.activeKey().id; got != "k2" {
+		t.Errorf("Reload() with invalid keyring active key = %v, want %v", got, "k2")
+	}
+}
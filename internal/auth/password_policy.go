@@ -0,0 +1,365 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Problem codes reported by PasswordPolicy.Check.
+const (
+	PasswordTooShort = "too_short"
+	PasswordTooWeak  = "too_weak"
+	PasswordBreached = "breached"
+)
+
+// PasswordProblem is one reason a password was rejected.
+type PasswordProblem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists everything wrong with a password at once, so a
+// user can fix it in one go.
+type PasswordPolicyError struct {
+	Problems []PasswordProblem
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		messages = append(messages, problem.Message)
+	}
+	return "password rejected: " + strings.Join(messages, "; ")
+}
+
+// PasswordPolicy decides which new passwords are acceptable. MinScore is on
+// the 0-4 scale of EstimatePasswordStrength. Breached is optional.
+type PasswordPolicy struct {
+	MinLength int
+	MinScore  int
+	Breached  *BreachedPasswords
+}
+
+// Check returns a *PasswordPolicyError if password is unacceptable, or another
+// error if the breached list can't be read. userInputs are things like the
+// email and username, which make a password weaker when it contains them.
+func (p *PasswordPolicy) Check(password string, userInputs ...string) error {
+	var problems []PasswordProblem
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		problems = append(problems, PasswordProblem{
+			Code:    PasswordTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters", p.MinLength),
+		})
+	}
+
+	if score := EstimatePasswordStrength(password, userInputs...); score < p.MinScore {
+		problems = append(problems, PasswordProblem{
+			Code:    PasswordTooWeak,
+			Message: "password is too easy to guess; avoid common words, names, sequences and repeated characters",
+		})
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("could not check breached passwords: %w", err)
+		}
+		if breached {
+			problems = append(problems, PasswordProblem{
+				Code:    PasswordBreached,
+				Message: "password has appeared in a data breach",
+			})
+		}
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// commonPasswordWords are fragments so common in passwords that containing
+// one adds almost nothing to the guessing effort.
+var commonPasswordWords = []string{
+	"password", "passw0rd", "letmein", "welcome", "admin", "login", "master",
+	"dragon", "monkey", "football", "baseball", "soccer", "hockey", "shadow",
+	"sunshine", "princess", "superman", "batman", "trustno1", "iloveyou",
+	"love", "secret", "qwerty", "hello", "freedom", "whatever", "starwars",
+	"chirpy", "chirp", "123456", "abc123", "summer", "winter", "spring",
+	"autumn", "changeme", "default", "user", "test",
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+}
+
+// patternLog10Guesses is roughly what guessing one recognised pattern
+// (a common word, a run, a sequence) costs an attacker, as a power of ten.
+const patternLog10Guesses = 2
+
+// EstimatePasswordStrength scores a password from 0 (trivial) to 4 (strong),
+// in the spirit of zxcvbn: common words, the user's own details, repeated
+// characters, sequences like "abcd" or "4321" and keyboard runs like "qwer"
+// each count as a single cheap guess, and whatever remains is charged by the
+// size of the character classes used. The thresholds are zxcvbn's: 10^3,
+// 10^6, 10^8 and 10^10 guesses.
+func EstimatePasswordStrength(password string, userInputs ...string) int {
+	lower := strings.ToLower(password)
+	patterns := 0
+
+	words := slices.Clone(commonPasswordWords)
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			input = local
+		}
+		if len(input) >= 3 {
+			words = append(words, input)
+		}
+	}
+	// Longest first, so "password" is removed before "pass" could be.
+	slices.SortFunc(words, func(a, b string) int { return len(b) - len(a) })
+	for _, word := range words {
+		for strings.Contains(lower, word) {
+			lower = strings.Replace(lower, word, "\x00", 1)
+			patterns++
+		}
+	}
+
+	runes := []rune(lower)
+	var remaining []rune
+	for i := 0; i < len(runes); {
+		if runes[i] == 0 {
+			i++
+			continue
+		}
+		if n := patternLength(runes[i:]); n >= 3 {
+			patterns++
+			i += n
+			continue
+		}
+		remaining = append(remaining, runes[i])
+		i++
+	}
+
+	pool := characterPool(password)
+	log10Guesses := float64(patterns)*patternLog10Guesses + float64(len(remaining))*math.Log10(float64(pool))
+
+	switch {
+	case log10Guesses < 3:
+		return 0
+	case log10Guesses < 6:
+		return 1
+	case log10Guesses < 8:
+		return 2
+	case log10Guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// patternLength returns how many runes at the start of s form a repeat
+// ("aaaa"), a sequence ("abcd", "9876") or a keyboard run ("qwer").
+func patternLength(s []rune) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+
+	best := 1
+	for _, step := range []rune{0, 1, -1} {
+		n := 1
+		for n < len(s) && s[n]-s[n-1] == step {
+			n++
+		}
+		best = max(best, n)
+	}
+
+	for _, row := range keyboardRows {
+		for _, r := range []string{row, reverse(row)} {
+			start := strings.IndexRune(r, s[0])
+			if start < 0 {
+				continue
+			}
+			n := 1
+			for n < len(s) && start+n < len(r) && rune(r[start+n]) == s[n] {
+				n++
+			}
+			best = max(best, n)
+		}
+	}
+
+	return best
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	slices.Reverse(runes)
+	return string(runes)
+}
+
+func characterPool(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	return max(pool, 1)
+}
+
+// BreachedPasswords is a local copy of known breached passwords, stored as
+// SHA-1 hashes in the format of the Have I Been Pwned "ordered by hash"
+// download: one hex hash per line, optionally followed by ":count", sorted by
+// hash. The full list runs to tens of gigabytes, so it stays on disk and each
+// lookup binary searches the file, reading only a few lines.
+type BreachedPasswords struct {
+	file *os.File
+	size int64
+}
+
+// maxBreachedLineLength bounds a line of the list: a hash, a count and a
+// CRLF line ending.
+const maxBreachedLineLength = 64
+
+// OpenBreachedPasswords opens a sorted breached password list. Only its
+// first line is checked here; checking every line would mean reading the
+// whole file.
+func OpenBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	bp := &BreachedPasswords{file: file, size: info.Size()}
+	if bp.size == 0 {
+		return bp, nil
+	}
+
+	_, line, err := bp.lineAfter(0)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+		file.Close()
+		return nil, fmt.Errorf("%s:1: not a SHA-1 hash", path)
+	}
+
+	return bp, nil
+}
+
+func (bp *BreachedPasswords) Close() error {
+	return bp.file.Close()
+}
+
+// Contains reports whether password is on the list. It only fails if the
+// file can't be read.
+func (bp *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	want := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// Every line starting in [lo, hi) is still a candidate, and lo is always
+	// the start of a line.
+	lo, hi := int64(0), bp.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := bp.lineAfter(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+		switch cmp := strings.Compare(strings.ToUpper(hash), want); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = start + int64(len(line)) + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+// lineAfter returns the first line that starts at or after offset, without
+// its newline, and the offset it starts at. Past the last line it returns the
+// file size.
+func (bp *BreachedPasswords) lineAfter(offset int64) (int64, string, error) {
+	// Reading from the byte before offset finds a line that starts exactly at
+	// offset, since that byte is the previous line's newline.
+	start := max(offset-1, 0)
+	buf := make([]byte, 2*maxBreachedLineLength)
+	n, err := bp.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	buf = buf[:n]
+	atEOF := start+int64(n) >= bp.size
+
+	if offset > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			if atEOF {
+				return bp.size, "", nil
+			}
+			return 0, "", errors.New("line too long for a breached password list")
+		}
+		start += int64(i + 1)
+		buf = buf[i+1:]
+	}
+
+	line, _, found := bytes.Cut(buf, []byte("\n"))
+	if !found && !atEOF {
+		return 0, "", errors.New("line too long for a breached password list")
+	}
+
+	return start, string(line), nil
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEstimatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		userInputs []string
+		wantMax    int
+		wantMin    int
+	}{
+		{
+			name:     "Common password",
+			password: "password",
+			wantMax:  0,
+		},
+		{
+			name:     "Repeated characters",
+			password: "aaaaaaaaaaaa",
+			wantMax:  0,
+		},
+		{
+			name:     "Sequence",
+			password: "abcdefghijkl",
+			wantMax:  0,
+		},
+		{
+			name:     "Keyboard run",
+			password: "qwertyuiop",
+			wantMax:  1,
+		},
+		{
+			name:       "Email local part",
+			password:   "waltwhite",
+			userInputs: []string{"waltwhite@example.com"},
+			wantMax:    0,
+		},
+		{
+			name:     "Long passphrase",
+			password: "correct horse battery staple",
+			wantMin:  4,
+			wantMax:  4,
+		},
+		{
+			name:     "Random mixed characters",
+			password: "x7#Kq9!vLm2$",
+			wantMin:  4,
+			wantMax:  4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimatePasswordStrength(tt.password, tt.userInputs...)
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("EstimatePasswordStrength() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	breached := writeBreachedPasswords(t, "hunter2hunter2", "Summer-Vacation-1998")
+
+	policy := &PasswordPolicy{
+		MinLength: 10,
+		MinScore:  2,
+		Breached:  breached,
+	}
+
+	tests := []struct {
+		name      string
+		password  string
+		wantCodes []string
+	}{
+		{
+			name:      "Strong password",
+			password:  "violet kettle drumming",
+			wantCodes: nil,
+		},
+		{
+			name:      "Empty password",
+			password:  "",
+			wantCodes: []string{PasswordTooShort, PasswordTooWeak},
+		},
+		{
+			name:      "Long but weak",
+			password:  "passwordpassword",
+			wantCodes: []string{PasswordTooWeak},
+		},
+		{
+			name:      "Breached",
+			password:  "Summer-Vacation-1998",
+			wantCodes: []string{PasswordBreached},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password)
+			if tt.wantCodes == nil {
+				if err != nil {
+					t.Errorf("Check() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Check() error = %v, want *PasswordPolicyError", err)
+			}
+			var gotCodes []string
+			for _, problem := range policyErr.Problems {
+				gotCodes = append(gotCodes, problem.Code)
+			}
+			if strings.Join(gotCodes, ",") != strings.Join(tt.wantCodes, ",") {
+				t.Errorf("Check() problems = %v, want %v", gotCodes, tt.wantCodes)
+			}
+		})
+	}
+}
+
+func TestBreachedPasswords_Contains(t *testing.T) {
+	var listed []string
+	for i := range 2000 {
+		listed = append(listed, fmt.Sprintf("password-%d", i))
+	}
+	breached := writeBreachedPasswords(t, listed...)
+
+	for _, password := range listed {
+		got, err := breached.Contains(password)
+		if err != nil {
+			t.Fatalf("Contains(%q) error = %v", password, err)
+		}
+		if !got {
+			t.Fatalf("Contains(%q) = false, want true", password)
+		}
+	}
+
+	for i := range 2000 {
+		password := fmt.Sprintf("not-listed-%d", i)
+		got, err := breached.Contains(password)
+		if err != nil {
+			t.Fatalf("Contains(%q) error = %v", password, err)
+		}
+		if got {
+			t.Fatalf("Contains(%q) = true, want false", password)
+		}
+	}
+}
+
+func TestOpenBreachedPasswords(t *testing.T) {
+	tests := []struct {
+		name      string
+		contents  string
+		password  string
+		want      bool
+		wantError bool
+	}{
+		{
+			name:     "Empty list",
+			contents: "",
+			password: "hunter2",
+			want:     false,
+		},
+		{
+			name:     "Single line without count or newline",
+			contents: breachedHash("hunter2"),
+			password: "hunter2",
+			want:     true,
+		},
+		{
+			name:     "Lowercase hashes",
+			contents: strings.ToLower(breachedHash("hunter2")) + ":7\n",
+			password: "hunter2",
+			want:     true,
+		},
+		{
+			name:      "Not a hash list",
+			contents:  "not-a-hash:3\n",
+			wantError: true,
+		},
+		{
+			name:      "Line too long",
+			contents:  strings.Repeat("A", 200) + "\n",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "breached.txt")
+			err := os.WriteFile(path, []byte(tt.contents), 0o600)
+			if err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+
+			breached, err := OpenBreachedPasswords(path)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("OpenBreachedPasswords() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+			defer breached.Close()
+
+			got, err := breached.Contains(tt.password)
+			if err != nil {
+				t.Fatalf("Contains() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func breachedHash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachedPasswords writes passwords sorted by hash in the Have I Been
+// Pwned format, with counts of varying length and CRLF line endings, and
+// opens them.
+func writeBreachedPasswords(t *testing.T, passwords ...string) *BreachedPasswords {
+	t.Helper()
+
+	var lines []string
+	for i, password := range passwords {
+		lines = append(lines, breachedHash(password)+":"+strconv.Itoa(i*i*37+1))
+	}
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	breached, err := OpenBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("OpenBreachedPasswords() error = %v", err)
+	}
+	t.Cleanup(func() { breached.Close() })
+	return breached
+}
@@ -0,0 +1,13 @@
+package auth
+
+// MakePasswordResetToken returns a token to email to a user who has
+// forgotten their password. It is as long and random as a refresh token.
+func MakePasswordResetToken() (string, error) {
+	return MakeRefreshToken()
+}
+
+// HashPasswordResetToken returns the value stored in place of a password
+// reset token.
+func HashPasswordResetToken(token string) string {
+	return HashRefreshToken(token)
+}
@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"errors"
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -82,3 +86,105 @@ func TestCheckPasswordHashBootDev(t *testing.T) {
 		})
 	}
 }
+
+func TestHashPassword_Argon2id(t *testing.T) {
+	hash, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("HashPassword() = %v, want argon2id PHC string", hash)
+	}
+
+	other, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if other == hash {
+		t.Errorf("HashPassword() returned the same hash twice; salt is not random")
+	}
+}
+
+func TestCheckPasswordHash_Bcrypt(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("legacyPassword"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+
+	err = CheckPasswordHash("legacyPassword", string(bcryptHash))
+	if err != nil {
+		t.Errorf("CheckPasswordHash() error = %v", err)
+	}
+	err = CheckPasswordHash("wrongPassword", string(bcryptHash))
+	if !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("CheckPasswordHash() error = %v, want %v", err, ErrPasswordMismatch)
+	}
+}
+
+func TestCheckPasswordHash_LongPassword(t *testing.T) {
+	// bcrypt ignored everything after 72 bytes; argon2id must not.
+	prefix := strings.Repeat("a", 72)
+	hash, err := HashPassword(prefix + "first")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	err = CheckPasswordHash(prefix+"second", hash)
+	if err == nil {
+		t.Errorf("CheckPasswordHash() accepted a password differing after 72 bytes")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hasher := &PasswordHasher{Params: DefaultArgon2Params}
+	current, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	weakerParams := DefaultArgon2Params
+	weakerParams.Iterations = 1
+	weaker, err := (&PasswordHasher{Params: weakerParams}).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{
+			name: "Current parameters",
+			hash: current,
+			want: false,
+		},
+		{
+			name: "Outdated parameters",
+			hash: weaker,
+			want: true,
+		},
+		{
+			name: "Bcrypt hash",
+			hash: string(bcryptHash),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+			err := hasher.Check("password", tt.hash)
+			if err != nil {
+				t.Errorf("Check() error = %v", err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 )
 
@@ -14,3 +15,11 @@ func MakeRefreshToken() (string, error) {
 
 	return hex.EncodeToString(bytes), nil
 }
+
+// HashRefreshToken returns the value stored in place of a refresh token. The
+// tokens are 256 random bits, so a plain SHA-256 can't be brute-forced and
+// needs no salt.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
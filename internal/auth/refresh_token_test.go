@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestMakeRefreshToken(t *testing.T) {
+	token1, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("MakeRefreshToken() error = %v", err)
+	}
+	token2, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("MakeRefreshToken() error = %v", err)
+	}
+
+	if len(token1) != 64 {
+		t.Errorf("MakeRefreshToken() length = %v, want 64", len(token1))
+	}
+	if token1 == token2 {
+		t.Errorf("MakeRefreshToken() returned the same token twice")
+	}
+}
+
+func TestHashRefreshToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		wantHash string
+	}{
+		{
+			name:     "Known value",
+			token:    "abc",
+			wantHash: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{
+			name:     "Empty token",
+			token:    "",
+			wantHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHash := HashRefreshToken(tt.token)
+			if gotHash != tt.wantHash {
+				t.Errorf("HashRefreshToken() = %v, want %v", gotHash, tt.wantHash)
+			}
+		})
+	}
+}
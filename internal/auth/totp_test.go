@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B,
+// "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; these are their last 6 digits.
+	tests := []struct {
+		unix     int64
+		wantCode string
+	}{
+		{unix: 59, wantCode: "287082"},
+		{unix: 1111111109, wantCode: "081804"},
+		{unix: 1111111111, wantCode: "050471"},
+		{unix: 1234567890, wantCode: "005924"},
+		{unix: 2000000000, wantCode: "279037"},
+		{unix: 20000000000, wantCode: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			gotCode, err := TOTPCode(rfc6238Secret, time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatalf("TOTPCode() error = %v", err)
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("TOTPCode() = %v, want %v", gotCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantErr  bool
+	}{
+		{
+			name:     "Current code",
+			code:     "050471",
+			wantStep: 1111111111 / 30,
+			wantErr:  false,
+		},
+		{
+			name:     "Previous period within skew",
+			code:     mustTOTPCode(t, now.Add(-30*time.Second)),
+			wantStep: 1111111111/30 - 1,
+			wantErr:  false,
+		},
+		{
+			name:    "Two periods ago",
+			code:    mustTOTPCode(t, now.Add(-60*time.Second)),
+			wantErr: true,
+		},
+		{
+			name:    "Wrong code",
+			code:    "000000",
+			wantErr: true,
+		},
+		{
+			name:    "Wrong length",
+			code:    "50471",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, err := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTOTP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %v, want %v", gotStep, tt.wantStep)
+			}
+		})
+	}
+}
+
+func mustTOTPCode(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := TOTPCode(rfc6238Secret, at)
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	return code
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+
+	code, err := TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	_, err = ValidateTOTP(secret, code, time.Now())
+	if err != nil {
+		t.Errorf("ValidateTOTP() error = %v", err)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Chirpy", "walt@example.com", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("TOTPURI() = %v, want otpauth://totp/...", uri)
+	}
+	if u.Path != "/Chirpy:walt@example.com" {
+		t.Errorf("TOTPURI() label = %v, want %v", u.Path, "/Chirpy:walt@example.com")
+	}
+	if got := u.Query().Get("secret"); got != rfc6238Secret {
+		t.Errorf("TOTPURI() secret = %v, want %v", got, rfc6238Secret)
+	}
+	if got := u.Query().Get("issuer"); got != "Chirpy" {
+		t.Errorf("TOTPURI() issuer = %v, want %v", got, "Chirpy")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes()
+	if err != nil {
+		t.Fatalf("MakeRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("MakeRecoveryCodes() returned %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 19 {
+			t.Errorf("recovery code %q has length %d, want 19", code, len(code))
+		}
+		if seen[code] {
+			t.Errorf("recovery code %q repeated", code)
+		}
+		seen[code] = true
+	}
+
+	code := codes[0]
+	if HashRecoveryCode(strings.ToUpper(code)) != HashRecoveryCode(code) {
+		t.Errorf("HashRecoveryCode() is case sensitive")
+	}
+	if HashRecoveryCode(strings.ReplaceAll(code, "-", "")) != HashRecoveryCode(code) {
+		t.Errorf("HashRecoveryCode() depends on dashes")
+	}
+}
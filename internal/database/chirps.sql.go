@@ -7,28 +7,32 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
-) RETURNING id, created_at, updated_at, body, user_id
+    $2,
+    $3
+) RETURNING id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body      string
+	UserID    uuid.UUID
+	InReplyTo uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID, arg.InReplyTo)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -36,6 +40,57 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.IsQuote,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of, is_quote)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL AND NOT is_quote
+DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote
+`
+
+type CreateRechirpParams struct {
+	Body      string
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+	IsQuote   bool
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp,
+		arg.Body,
+		arg.UserID,
+		arg.RechirpOf,
+		arg.IsQuote,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.IsQuote,
 	)
 	return i, err
 }
@@ -60,7 +115,8 @@ func (q *Queries) DeleteChirps(ctx context.Context) error {
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
 ORDER BY created_at
 `
 
@@ -79,6 +135,62 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0
+    FROM chirps c
+    WHERE c.id = $1
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.is_quote FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY ancestors.depth DESC
+`
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
 		); err != nil {
 			return nil, err
 		}
@@ -94,8 +206,9 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
 WHERE id = $1
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
 `
 
 func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -107,12 +220,75 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.IsQuote,
 	)
 	return i, err
 }
 
+const getChirpReplies = `-- name: GetChirpReplies :many
+WITH RECURSIVE replies(id, depth) AS (
+    SELECT c.id, 1
+    FROM chirps c
+    WHERE c.in_reply_to = $1
+    UNION ALL
+    SELECT c.id, r.depth + 1
+    FROM chirps c
+    JOIN replies r ON c.in_reply_to = r.id
+    WHERE r.depth < $2
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.is_quote FROM chirps
+JOIN replies ON chirps.id = replies.id
+WHERE chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at, chirps.id
+LIMIT $3
+`
+
+type GetChirpRepliesParams struct {
+	ChirpID  uuid.UUID
+	MaxDepth int32
+	Limit    int32
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies, arg.ChirpID, arg.MaxDepth, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
 WHERE user_id = $1
 ORDER BY created_at
 `
@@ -132,6 +308,234 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByHashtag = `-- name: GetChirpsByHashtag :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.is_quote FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.name = $1
+AND (
+    $2::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < ($2, $3::uuid)
+)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type GetChirpsByHashtagParams struct {
+	Name            string
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsByHashtag(ctx context.Context, arg GetChirpsByHashtagParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByHashtag,
+		arg.Name,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE id = ANY($1::uuid[])
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+`
+
+func (q *Queries) GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMentions = `-- name: GetMentions :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.is_quote FROM chirps
+JOIN chirp_mentions ON chirp_mentions.chirp_id = chirps.id
+WHERE chirp_mentions.user_id = $1
+AND (
+    $2::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < ($2, $3::uuid)
+)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type GetMentionsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetMentions(ctx context.Context, arg GetMentionsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getMentions,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE (
+    user_id = $1
+    OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2, $3::uuid)
+)
+AND ($4::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetTimelineParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	IncludeRechirps bool
+	Limit           int32
+}
+
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.IncludeRechirps,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
 		); err != nil {
 			return nil, err
 		}
@@ -145,3 +549,214 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) > ($2, $3::uuid)
+)
+AND ($4::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	IncludeRechirps bool
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.IncludeRechirps,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2, $3::uuid)
+)
+AND ($4::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	IncludeRechirps bool
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.IncludeRechirps,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, reply_count, like_count, rechirp_of, is_quote FROM chirps
+WHERE to_tsvector('english', body) @@ to_tsquery('english', $1)
+AND ($2::uuid IS NULL OR user_id = $2)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY ts_rank(to_tsvector('english', body), to_tsquery('english', $1)) DESC, created_at DESC
+LIMIT $3
+`
+
+type SearchChirpsParams struct {
+	Query    string
+	AuthorID uuid.NullUUID
+	Limit    int32
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps, arg.Query, arg.AuthorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.ReplyCount,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.IsQuote,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+WITH current_chirp AS (
+    SELECT id, body, updated_at FROM chirps
+    WHERE chirps.id = $1
+    FOR UPDATE
+), revision AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), current_chirp.id, current_chirp.body, current_chirp.updated_at
+    FROM current_chirp
+)
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+FROM current_chirp
+WHERE chirps.id = current_chirp.id
+RETURNING chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.reply_count, chirps.like_count, chirps.rechirp_of, chirps.is_quote
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.ReplyCount,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.IsQuote,
+	)
+	return i, err
+}
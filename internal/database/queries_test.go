@@ -0,0 +1,311 @@
+package database
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	queryNamePattern   = regexp.MustCompile(`(?m)^-- name: (\w+) :\w+\s*$`)
+	generatedPattern   = regexp.MustCompile("(?s)-- name: (\\w+) :\\w+\\s*\n(.*?)\n`")
+	placeholderPattern = regexp.MustCompile(`sqlc\.n?arg\('\w+'\)|\$\d+`)
+	selectListPattern  = regexp.MustCompile(`SELECT [^\n]*? FROM`)
+	returningPattern   = regexp.MustCompile(`RETURNING [^\n]*`)
+	whitespacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// normalizeQuery drops what sqlc rewrites when it generates code: parameter
+// names, expanded column lists and formatting.
+func normalizeQuery(query string) string {
+	query = placeholderPattern.ReplaceAllString(query, "?")
+	query = selectListPattern.ReplaceAllString(query, "SELECT * FROM")
+	query = returningPattern.ReplaceAllString(query, "RETURNING *")
+	query = whitespacePattern.ReplaceAllString(query, " ")
+	return strings.TrimSuffix(strings.TrimSpace(query), ";")
+}
+
+func parseQueryFile(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	queries := map[string]string{}
+	source := string(data)
+	matches := queryNamePattern.FindAllStringSubmatchIndex(source, -1)
+	for i, m := range matches {
+		end := len(source)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		queries[source[m[2]:m[3]]] = source[m[1]:end]
+	}
+	return queries
+}
+
+// TestGeneratedQueriesMatchSource catches queries that were changed in
+// sql/queries without rerunning sqlc, or generated code edited by hand.
+func TestGeneratedQueriesMatchSource(t *testing.T) {
+	paths, err := filepath.Glob("../../sql/queries/*.sql")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no query files found")
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		t.Run(name, func(t *testing.T) {
+			source := parseQueryFile(t, path)
+
+			data, err := os.ReadFile(name + ".go")
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			generated := map[string]string{}
+			for _, m := range generatedPattern.FindAllStringSubmatch(string(data), -1) {
+				generated[m[1]] = m[2]
+			}
+
+			for query, sql := range source {
+				got, ok := generated[query]
+				if !ok {
+					t.Errorf("%s has no generated code", query)
+					continue
+				}
+				if normalizeQuery(got) != normalizeQuery(sql) {
+					t.Errorf("%s is out of date:\n  query:     %s\n  generated: %s", query, normalizeQuery(sql), normalizeQuery(got))
+				}
+			}
+			for query := range generated {
+				if _, ok := source[query]; !ok {
+					t.Errorf("%s is generated but not in %s", query, name)
+				}
+			}
+		})
+	}
+}
+
+var (
+	insertColumnsPattern = regexp.MustCompile(`(?s)INSERT INTO \w+ \(([^)]*)\)\s*VALUES\s*\(`)
+	outputListPattern    = regexp.MustCompile(`(?s)^\s*(?:WITH .*?)?SELECT\s+(?:DISTINCT\s+)?(.*?)\s+FROM\s|RETURNING\s+(.*)$`)
+	maxParamPattern      = regexp.MustCompile(`\$(\d+)`)
+	aliasPattern         = regexp.MustCompile(`(?:^|\s)AS\s+(\w+)$`)
+)
+
+// stripParens drops everything inside parentheses, leaving only the top level
+// of a statement so subqueries and function arguments don't count as columns.
+func stripParens(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// closingParen returns the index of the parenthesis that closes the one
+// opened just before s.
+func closingParen(s string) int {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return len(s)
+}
+
+// splitTopLevel splits a list on the commas that aren't nested in
+// parentheses.
+func splitTopLevel(list string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// goFieldName is the struct field sqlc names after a column.
+func goFieldName(column string) string {
+	if m := aliasPattern.FindStringSubmatch(column); m != nil {
+		column = m[1]
+	}
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		column = column[i+1:]
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(strings.ToLower(strings.TrimSpace(column)), "_") {
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+type generatedQuery struct {
+	name  string
+	sql   string
+	args  int
+	scans []string
+}
+
+// parseGeneratedQueries reads each query's SQL together with how its method
+// calls it: how many arguments it binds and which fields it scans into.
+func parseGeneratedQueries(t *testing.T, path string) []generatedQuery {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	consts := map[string]string{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			lit := value.Values[0].(*ast.BasicLit)
+			consts[value.Names[0].Name] = strings.Trim(lit.Value, "`")
+		}
+	}
+
+	var queries []generatedQuery
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil {
+			continue
+		}
+		query := generatedQuery{name: fn.Name.Name}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "QueryContext", "QueryRowContext", "ExecContext":
+				ident := call.Args[1].(*ast.Ident)
+				query.sql = consts[ident.Name]
+				query.args = len(call.Args) - 2
+			case "Scan":
+				for _, arg := range call.Args {
+					// Array columns are scanned through pq.Array(&i.Field).
+					if wrapped, ok := arg.(*ast.CallExpr); ok {
+						arg = wrapped.Args[0]
+					}
+					target := arg.(*ast.UnaryExpr).X
+					if field, ok := target.(*ast.SelectorExpr); ok {
+						query.scans = append(query.scans, field.Sel.Name)
+					} else {
+						query.scans = append(query.scans, "")
+					}
+				}
+			}
+			return true
+		})
+		queries = append(queries, query)
+	}
+	return queries
+}
+
+// TestGeneratedQueriesBindAndScan catches generated code whose SQL and Go
+// disagree, which comparing the SQL text alone can't: an INSERT naming more
+// columns than it supplies values for, parameters that aren't bound, or rows
+// scanned into the wrong fields.
+func TestGeneratedQueriesBindAndScan(t *testing.T) {
+	paths, err := filepath.Glob("*.sql.go")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			for _, query := range parseGeneratedQueries(t, path) {
+				sql := query.sql[strings.Index(query.sql, "\n")+1:]
+
+				if m := insertColumnsPattern.FindStringSubmatchIndex(sql); m != nil {
+					columns := splitTopLevel(sql[m[2]:m[3]])
+					values := splitTopLevel(sql[m[1] : m[1]+closingParen(sql[m[1]:])])
+					if len(columns) != len(values) {
+						t.Errorf("%s inserts %d columns but supplies %d values", query.name, len(columns), len(values))
+					}
+				}
+
+				params := 0
+				for _, m := range maxParamPattern.FindAllStringSubmatch(sql, -1) {
+					n, _ := strconv.Atoi(m[1])
+					params = max(params, n)
+				}
+				if params != query.args {
+					t.Errorf("%s uses %d parameters but binds %d", query.name, params, query.args)
+				}
+
+				if query.scans == nil {
+					continue
+				}
+				m := outputListPattern.FindStringSubmatch(stripParens(sql))
+				if m == nil {
+					t.Errorf("%s scans a row but has no SELECT or RETURNING list", query.name)
+					continue
+				}
+				columns := splitTopLevel(m[1] + m[2])
+				if len(columns) != len(query.scans) {
+					t.Errorf("%s returns %d columns but scans %d", query.name, len(columns), len(query.scans))
+					continue
+				}
+				if len(query.scans) == 1 && query.scans[0] == "" {
+					continue
+				}
+				for i, column := range columns {
+					if want := goFieldName(column); query.scans[i] != want {
+						t.Errorf("%s scans column %q into %s, want %s", query.name, column, query.scans[i], want)
+					}
+				}
+			}
+		})
+	}
+}
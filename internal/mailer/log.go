@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer writes each message to w instead of delivering it, so links in
+// verification emails can be followed in development without a mail server.
+type LogMailer struct {
+	From string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogMailer(w io.Writer, from string) *LogMailer {
+	return &LogMailer{From: from, w: w}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	data, err := format(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = io.WriteString(m.w, "---- mail ----\r\n")
+	if err != nil {
+		return err
+	}
+	_, err = m.w.Write(data)
+	return err
+}
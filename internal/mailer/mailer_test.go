@@ -0,0 +1,157 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogMailer(t *testing.T) {
+	var out strings.Builder
+	m := NewLogMailer(&out, "Chirpy <noreply@chirpy.example>")
+
+	err := m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "Hello\nFollow this link.",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"From: Chirpy <noreply@chirpy.example>\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Verify your email\r\n",
+		"Message-ID: <",
+		"@chirpy.example>\r\n",
+		"\r\n\r\nHello\r\nFollow this link.\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Send() wrote %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSendRejectsBadHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{
+			name: "newline in subject",
+			msg:  Message{To: "user@example.com", Subject: "Hi\r\nBcc: victim@example.com"},
+		},
+		{
+			name: "newline in recipient",
+			msg:  Message{To: "user@example.com\nBcc: victim@example.com", Subject: "Hi"},
+		},
+		{
+			name: "invalid recipient",
+			msg:  Message{To: "not an address", Subject: "Hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			m := NewLogMailer(&out, "noreply@chirpy.example")
+			err := m.Send(context.Background(), tt.msg)
+			if err == nil {
+				t.Errorf("Send() error = nil, want an error")
+			}
+			if out.Len() != 0 {
+				t.Errorf("Send() wrote %q, want nothing", out.String())
+			}
+		})
+	}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	type received struct {
+		from, to, data string
+	}
+	done := make(chan received, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+
+		var got received
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL":
+				got.from = arg
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				got.to = arg
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				got.data = string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				done <- got
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	m := NewSMTPMailer(host, port, "", "", "Chirpy <noreply@chirpy.example>")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = m.Send(ctx, Message{
+		To:      "User <user@example.com>",
+		Subject: "Hello",
+		Body:    "Line one\n.line two",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := <-done
+	if got.from != "FROM:<noreply@chirpy.example>" {
+		t.Errorf("MAIL %s, want FROM:<noreply@chirpy.example>", got.from)
+	}
+	if got.to != "TO:<user@example.com>" {
+		t.Errorf("RCPT %s, want TO:<user@example.com>", got.to)
+	}
+	body := bufio.NewScanner(strings.NewReader(got.data))
+	var lines []string
+	for body.Scan() {
+		lines = append(lines, body.Text())
+	}
+	if n := len(lines); n < 2 || lines[n-2] != "Line one" || lines[n-1] != ".line two" {
+		t.Errorf("DATA ended with %q, want the body lines", lines)
+	}
+}
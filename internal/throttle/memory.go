@@ -0,0 +1,76 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps failures in process. It is enough for a single instance;
+// deployments with several instances should share a PostgresStore.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]State{}}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockouts []time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.states[key]
+	if now.Sub(state.LastFailureAt) > window {
+		state.Failures = 0
+	}
+	lockout := lockouts[min(state.Failures, len(lockouts)-1)]
+	if now.Before(state.LastFailureAt.Add(lockout)) {
+		return false, nil
+	}
+	state.Failures++
+	state.LastFailureAt = now
+	s.states[key] = state
+
+	// Forget stale keys now and then so memory doesn't grow without bound.
+	if len(s.states)%1024 == 0 {
+		for k, st := range s.states {
+			if now.Sub(st.LastFailureAt) > window {
+				delete(s.states, k)
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return nil
+	}
+	state.Failures--
+	if state.Failures <= 0 {
+		delete(s.states, key)
+		return nil
+	}
+	s.states[key] = state
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
@@ -0,0 +1,68 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+// PostgresStore keeps failures in the login_throttles table, so every
+// instance behind a load balancer sees the same counts.
+type PostgresStore struct {
+	db *database.Queries
+}
+
+func NewPostgresStore(db *database.Queries) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (State, error) {
+	row, err := s.db.GetLoginThrottle(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return State{}, nil
+		}
+		return State{}, err
+	}
+	return State{Failures: int(row.Failures), LastFailureAt: row.LastFailureAt}, nil
+}
+
+// Reserve checks the lockout and counts the attempt in one upsert. A key that
+// is still locked out leaves the row untouched, so no row comes back.
+func (s *PostgresStore) Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockouts []time.Duration) (bool, error) {
+	lockoutSeconds := make([]float64, len(lockouts))
+	for i, lockout := range lockouts {
+		lockoutSeconds[i] = lockout.Seconds()
+	}
+
+	_, err := s.db.ReserveLoginAttempt(ctx, database.ReserveLoginAttemptParams{
+		Key:            key,
+		AttemptedAt:    now,
+		WindowStart:    now.Add(-window),
+		LockoutSeconds: lockoutSeconds,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	return s.db.ReleaseLoginAttempt(ctx, key)
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.db.DeleteLoginThrottle(ctx, key)
+}
+
+// Prune deletes keys whose last failure is older than before. They would be
+// ignored anyway; this just keeps the table small.
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return s.db.DeleteStaleLoginThrottles(ctx, before)
+}
@@ -0,0 +1,126 @@
+// Package throttle slows down repeated failures, such as wrong passwords,
+// with delays that double after a number of free attempts.
+package throttle
+
+import (
+	"context"
+	"time"
+)
+
+// State is what a Store remembers about one key.
+type State struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// Store keeps failure counts. Implementations must make Reserve check and
+// count an attempt in one atomic step, or concurrent attempts could all get
+// in under the limit before any of them is counted.
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	// Reserve counts an attempt at now as a failure and returns true, unless
+	// key is still locked out, in which case it changes nothing and returns
+	// false. lockouts[n] is how long n failures lock a key out, the last
+	// entry standing for any higher count. Failures more than window before
+	// now are forgotten, so the count starts again from one.
+	Reserve(ctx context.Context, key string, now time.Time, window time.Duration, lockouts []time.Duration) (bool, error)
+	// Release takes back one reserved attempt that didn't fail.
+	Release(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Limiter locks a key out for a while once it has failed more than
+// FreeAttempts times. The first lockout lasts BaseDelay and each further
+// failure doubles it, up to MaxDelay. A key is forgotten Window after its
+// last failure.
+type Limiter struct {
+	Store        Store
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Window       time.Duration
+}
+
+// Check returns how long the caller must wait before key may try again, or
+// zero if it may try now.
+func (l *Limiter) Check(ctx context.Context, key string, now time.Time) (time.Duration, error) {
+	state, err := l.Store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if state.Failures == 0 || now.Sub(state.LastFailureAt) > l.Window {
+		return 0, nil
+	}
+
+	lockedUntil := state.LastFailureAt.Add(l.delay(state.Failures))
+	if !now.Before(lockedUntil) {
+		return 0, nil
+	}
+	return lockedUntil.Sub(now), nil
+}
+
+// Reserve lets key try now unless it is locked out, counting the attempt as
+// a failure up front so concurrent attempts can't all get in before any of
+// them fails. It returns how long the caller must wait, or zero if it may go
+// ahead. An attempt that turns out to succeed is handed back with Success or
+// Release.
+func (l *Limiter) Reserve(ctx context.Context, key string, now time.Time) (time.Duration, error) {
+	lockouts := l.lockouts()
+	for {
+		reserved, err := l.Store.Reserve(ctx, key, now, l.Window, lockouts)
+		if err != nil {
+			return 0, err
+		}
+		if reserved {
+			return 0, nil
+		}
+
+		wait, err := l.Check(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		if wait > 0 {
+			return wait, nil
+		}
+		// Someone else's attempt was released in between; try again.
+	}
+}
+
+// Release takes back an attempt reserved by key that didn't fail, leaving
+// any other failures counted.
+func (l *Limiter) Release(ctx context.Context, key string) error {
+	return l.Store.Release(ctx, key)
+}
+
+// Success forgets key's failures.
+func (l *Limiter) Success(ctx context.Context, key string) error {
+	return l.Store.Reset(ctx, key)
+}
+
+// lockouts lists the delay for each failure count until it stops growing.
+func (l *Limiter) lockouts() []time.Duration {
+	lockouts := []time.Duration{0}
+	for n := 1; ; n++ {
+		delay := l.delay(n)
+		if n > l.FreeAttempts && delay == lockouts[n-1] {
+			return lockouts
+		}
+		lockouts = append(lockouts, delay)
+	}
+}
+
+func (l *Limiter) delay(failures int) time.Duration {
+	over := failures - l.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := l.BaseDelay
+	for i := 1; i < over; i++ {
+		delay *= 2
+		if delay >= l.MaxDelay {
+			return l.MaxDelay
+		}
+	}
+	return min(delay, l.MaxDelay)
+}
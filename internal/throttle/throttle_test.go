@@ -0,0 +1,204 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLimiter() *Limiter {
+	return &Limiter{
+		Store:        NewMemoryStore(),
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     10 * time.Second,
+		Window:       time.Hour,
+	}
+}
+
+func TestLimiter_ProgressiveDelay(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		failures  int
+		wantDelay time.Duration
+	}{
+		{failures: 1, wantDelay: 0},
+		{failures: 3, wantDelay: 0},
+		{failures: 4, wantDelay: time.Second},
+		{failures: 5, wantDelay: 2 * time.Second},
+		{failures: 6, wantDelay: 4 * time.Second},
+		{failures: 7, wantDelay: 8 * time.Second},
+		{failures: 8, wantDelay: 10 * time.Second},
+		{failures: 20, wantDelay: 10 * time.Second},
+	}
+
+	recorded := 0
+	for _, tt := range tests {
+		for recorded < tt.failures {
+			wait, err := limiter.Reserve(ctx, "email:walt@example.com", now)
+			if err != nil {
+				t.Fatalf("Reserve() error = %v", err)
+			}
+			if wait > 0 {
+				now = now.Add(wait)
+				continue
+			}
+			recorded++
+		}
+
+		gotDelay, err := limiter.Check(ctx, "email:walt@example.com", now)
+		if err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if gotDelay != tt.wantDelay {
+			t.Errorf("after %d failures Check() = %v, want %v", tt.failures, gotDelay, tt.wantDelay)
+		}
+	}
+
+	gotDelay, err := limiter.Check(ctx, "email:walt@example.com", now.Add(10*time.Second))
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if gotDelay != 0 {
+		t.Errorf("Check() after lockout expired = %v, want 0", gotDelay)
+	}
+
+	gotDelay, err = limiter.Check(ctx, "email:jesse@example.com", now)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if gotDelay != 0 {
+		t.Errorf("Check() for another key = %v, want 0", gotDelay)
+	}
+}
+
+func TestLimiter_SuccessResets(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		limiter.Reserve(ctx, "ip:192.0.2.1", now)
+	}
+	err := limiter.Success(ctx, "ip:192.0.2.1")
+	if err != nil {
+		t.Fatalf("Success() error = %v", err)
+	}
+
+	gotDelay, err := limiter.Check(ctx, "ip:192.0.2.1", now)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if gotDelay != 0 {
+		t.Errorf("Check() after Success() = %v, want 0", gotDelay)
+	}
+}
+
+func TestLimiter_WindowForgetsOldFailures(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter()
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		limiter.Reserve(ctx, "ip:192.0.2.1", start)
+	}
+
+	later := start.Add(2 * time.Hour)
+	wait, err := limiter.Reserve(ctx, "ip:192.0.2.1", later)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if wait != 0 {
+		t.Errorf("Reserve() after window = %v, want 0", wait)
+	}
+
+	state, err := limiter.Store.Get(ctx, "ip:192.0.2.1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if state.Failures != 1 {
+		t.Errorf("failures after window = %v, want 1", state.Failures)
+	}
+}
+
+func TestLimiter_ReserveIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter()
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, err := limiter.Reserve(ctx, "email:walt@example.com", now)
+			if err != nil {
+				t.Errorf("Reserve() error = %v", err)
+				return
+			}
+			if wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The free attempts get in, plus the one whose failure starts the first
+	// lockout.
+	if want := limiter.FreeAttempts + 1; allowed != want {
+		t.Errorf("concurrent attempts allowed = %v, want %v", allowed, want)
+	}
+}
+
+func TestLimiter_ReleaseGivesBackOneAttempt(t *testing.T) {
+	ctx := context.Background()
+	limiter := newTestLimiter()
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		limiter.Reserve(ctx, "ip:192.0.2.1", now)
+	}
+	err := limiter.Release(ctx, "ip:192.0.2.1")
+	if err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	state, err := limiter.Store.Get(ctx, "ip:192.0.2.1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if state.Failures != 3 {
+		t.Errorf("failures after Release() = %v, want 3", state.Failures)
+	}
+
+	wait, err := limiter.Reserve(ctx, "ip:192.0.2.1", now)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if wait != 0 {
+		t.Errorf("Reserve() after Release() = %v, want 0", wait)
+	}
+}
+
+func TestLimiter_Lockouts(t *testing.T) {
+	limiter := newTestLimiter()
+
+	got := limiter.lockouts()
+	want := []time.Duration{0, 0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	if len(got) != len(want) {
+		t.Fatalf("lockouts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("lockouts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/brettlazarine/Chirpy/internal/throttle"
+)
+
+// Failed logins are throttled per account and per client IP. The IP limit is
+// looser because many users can share an address behind NAT; it is there to
+// slow down one client trying many accounts.
+const (
+	accountFreeLoginAttempts = 5
+	ipFreeLoginAttempts      = 20
+	loginLockoutBaseDelay    = time.Second
+	loginLockoutMaxDelay     = 15 * time.Minute
+	accountLoginWindow       = 24 * time.Hour
+	ipLoginWindow            = time.Hour
+)
+
+// newLoginLimiters builds the account and IP limiters on the store named by
+// LOGIN_THROTTLE_STORE: "memory" for a single instance or "postgres" to share
+// counts between instances.
+func newLoginLimiters(storeName string, db *database.Queries) (accountLimiter, ipLimiter *throttle.Limiter) {
+	var store throttle.Store
+	switch storeName {
+	case "", "memory":
+		store = throttle.NewMemoryStore()
+	case "postgres":
+		pgStore := throttle.NewPostgresStore(db)
+		go pruneLoginThrottles(pgStore)
+		store = pgStore
+	default:
+		log.Fatalf("LOGIN_THROTTLE_STORE must be memory or postgres: %q", storeName)
+	}
+
+	accountLimiter = &throttle.Limiter{
+		Store:        store,
+		FreeAttempts: accountFreeLoginAttempts,
+		BaseDelay:    loginLockoutBaseDelay,
+		MaxDelay:     loginLockoutMaxDelay,
+		Window:       accountLoginWindow,
+	}
+	ipLimiter = &throttle.Limiter{
+		Store:        store,
+		FreeAttempts: ipFreeLoginAttempts,
+		BaseDelay:    loginLockoutBaseDelay,
+		MaxDelay:     loginLockoutMaxDelay,
+		Window:       ipLoginWindow,
+	}
+	return accountLimiter, ipLimiter
+}
+
+func pruneLoginThrottles(store *throttle.PostgresStore) {
+	for range time.Tick(time.Hour) {
+		_, err := store.Prune(context.Background(), time.Now().UTC().Add(-accountLoginWindow))
+		if err != nil {
+			log.Printf("could not prune login throttles: %v", err)
+		}
+	}
+}
+
+// reserveLoginAttempt counts the attempt as a failure against the account
+// and the client before the credentials are checked, so parallel guesses
+// can't all get in before any of them is counted. It responds with 429 and
+// returns false if either is locked out. An attempt that succeeds is handed
+// back with recordLoginSuccess.
+func (cfg *apiConfig) reserveLoginAttempt(w http.ResponseWriter, r *http.Request, accountKey string) bool {
+	now := time.Now().UTC()
+	ipKey := ipThrottleKey(r)
+
+	accountWait, err := cfg.accountLimiter.Reserve(r.Context(), accountKey, now)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not check login attempts", err)
+		return false
+	}
+	ipWait, err := cfg.ipLimiter.Reserve(r.Context(), ipKey, now)
+	if err != nil {
+		if accountWait == 0 {
+			cfg.releaseLoginAttempt(r, cfg.accountLimiter, accountKey)
+		}
+		respondWithError(w, http.StatusInternalServerError, "could not check login attempts", err)
+		return false
+	}
+
+	wait := max(accountWait, ipWait)
+	if wait > 0 {
+		// An attempt that was turned away doesn't count against whichever
+		// limit it got past.
+		if accountWait == 0 {
+			cfg.releaseLoginAttempt(r, cfg.accountLimiter, accountKey)
+		}
+		if ipWait == 0 {
+			cfg.releaseLoginAttempt(r, cfg.ipLimiter, ipKey)
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondWithError(w, http.StatusTooManyRequests, "too many login attempts; try again later", nil)
+		return false
+	}
+	return true
+}
+
+// recordLoginSuccess clears the account's failures and hands back the
+// client's reservation. The client's other failures are left alone, or an
+// attacker could reset them by logging in to an account of their own between
+// guesses.
+func (cfg *apiConfig) recordLoginSuccess(r *http.Request, accountKey string) {
+	err := cfg.accountLimiter.Success(r.Context(), accountKey)
+	if err != nil {
+		log.Printf("could not reset failed logins for %s: %v", accountKey, err)
+	}
+	cfg.releaseLoginAttempt(r, cfg.ipLimiter, ipThrottleKey(r))
+}
+
+func (cfg *apiConfig) releaseLoginAttempt(r *http.Request, limiter *throttle.Limiter, key string) {
+	err := limiter.Release(r.Context(), key)
+	if err != nil {
+		log.Printf("could not release login attempt for %s: %v", key, err)
+	}
+}
+
+func ipThrottleKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
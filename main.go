@@ -5,9 +5,17 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/brettlazarine/Chirpy/internal/auth"
 	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/brettlazarine/Chirpy/internal/mailer"
+	"github.com/brettlazarine/Chirpy/internal/throttle"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -15,9 +23,32 @@ import (
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
-	jwtSecret      string
+	jwtKeyring     *auth.Keyring
 	polkaKey       string
+	passwordHasher *auth.PasswordHasher
+	passwordPolicy *auth.PasswordPolicy
+	mailer         mailer.Mailer
+	publicURL      string
+
+	// verifiedEmailRequired keeps users from posting until they have
+	// verified their email address.
+	verifiedEmailRequired bool
+
+	// dummyPasswordHash is checked against when a login names an unknown
+	// email, so that takes as long as a wrong password.
+	dummyPasswordHash string
+	accountLimiter    *throttle.Limiter
+	ipLimiter         *throttle.Limiter
+
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	jwtLeeway       time.Duration
+
+	// accountDeletionGracePeriod is how long a user has to change their
+	// mind after asking for their account to be deleted.
+	accountDeletionGracePeriod time.Duration
 }
 
 func main() {
@@ -27,7 +58,6 @@ func main() {
 	godotenv.Load()
 	dbURL := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
-	jwtSecret := os.Getenv("JWT_SECRET")
 	polkaKey := os.Getenv("POLKA_KEY")
 	if dbURL == "" {
 		log.Fatalf("DB_URL environment variable is required")
@@ -35,12 +65,22 @@ func main() {
 	if platform == "" {
 		log.Fatalf("PLATFORM environment variable is required")
 	}
-	if jwtSecret == "" {
-		log.Fatalf("JWT_SECRET environment variable is required")
-	}
 	if polkaKey == "" {
 		log.Fatalf("POLKA_KEY environment variable is required")
 	}
+	jwtKeyring := keyringFromEnv()
+	accessTokenTTL := durationFromEnv("ACCESS_TOKEN_TTL", time.Hour)
+	refreshTokenTTL := durationFromEnv("REFRESH_TOKEN_TTL", 60*24*time.Hour)
+	jwtLeeway := durationFromEnv("JWT_LEEWAY", 30*time.Second)
+	accountDeletionGracePeriod := durationFromEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	passwordHasher := &auth.PasswordHasher{Params: argon2ParamsFromEnv()}
+	passwordPolicy := passwordPolicyFromEnv()
+	mailSender := mailerFromEnv()
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+	verifiedEmailRequired := boolFromEnv("REQUIRE_VERIFIED_EMAIL", false)
 
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -48,12 +88,37 @@ func main() {
 	}
 	dbQueries := database.New(dbConn)
 
+	dummyPasswordHash, err := passwordHasher.Hash("not a real password")
+	if err != nil {
+		log.Fatalf("error hashing dummy password: %v", err)
+	}
+	accountLimiter, ipLimiter := newLoginLimiters(os.Getenv("LOGIN_THROTTLE_STORE"), dbQueries)
+	go purgeDeletedAccounts(dbQueries, accountDeletionGracePeriod)
+	go pruneDataExports(dbQueries)
+
 	cfg := &apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
+		dbConn:         dbConn,
 		platform:       platform,
-		jwtSecret:      jwtSecret,
+		jwtKeyring:     jwtKeyring,
 		polkaKey:       polkaKey,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		mailer:         mailSender,
+		publicURL:      publicURL,
+
+		verifiedEmailRequired: verifiedEmailRequired,
+
+		dummyPasswordHash: dummyPasswordHash,
+		accountLimiter:    accountLimiter,
+		ipLimiter:         ipLimiter,
+
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		jwtLeeway:       jwtLeeway,
+
+		accountDeletionGracePeriod: accountDeletionGracePeriod,
 	}
 
 	mux := http.NewServeMux()
@@ -62,20 +127,58 @@ func main() {
 	// API routes
 	mux.Handle("/app/", cfg.middlewareMetricsInc(fileServer))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.handlerJWKS)
 
 	mux.HandleFunc("POST /api/users", cfg.handlerCreateUser)
 	mux.HandleFunc("PUT /api/users", cfg.handlerUpdateUser)
+	mux.HandleFunc("DELETE /api/users/me", cfg.handlerDeleteUser)
+	mux.HandleFunc("POST /api/users/me/restore", cfg.handlerCancelDeletion)
+	mux.HandleFunc("POST /api/users/me/export", cfg.handlerCreateDataExport)
+	mux.HandleFunc("GET /api/users/me/export/{exportID}", cfg.handlerGetDataExport)
+	mux.HandleFunc("POST /api/users/verify", cfg.handlerVerifyEmail)
+	mux.HandleFunc("GET /api/users/{username}", cfg.handlerGetProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.handlerGetMentions)
+	mux.HandleFunc("POST /api/users/me/verification", cfg.handlerResendVerification)
+	mux.HandleFunc("POST /api/users/me/totp", cfg.handlerEnrollTOTP)
+	mux.HandleFunc("POST /api/users/me/totp/activate", cfg.handlerActivateTOTP)
 
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerPolkaWebhook)
 
+	mux.HandleFunc("POST /api/password/forgot", cfg.handlerForgotPassword)
+	mux.HandleFunc("POST /api/password/reset", cfg.handlerResetPassword)
+
 	mux.HandleFunc("POST /api/login", cfg.handlerLogin)
+	mux.HandleFunc("POST /api/login/mfa", cfg.handlerLoginMFA)
 	mux.HandleFunc("POST /api/refresh", cfg.handlerRefreshToken)
 	mux.HandleFunc("POST /api/revoke", cfg.handlerRevokeToken)
+	mux.HandleFunc("GET /api/sessions", cfg.handlerGetSessions)
+	mux.HandleFunc("DELETE /api/sessions", cfg.handlerRevokeAllSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.handlerRevokeSession)
+	mux.HandleFunc("POST /api/tokens", cfg.handlerCreateAPIToken)
+	mux.HandleFunc("GET /api/tokens", cfg.handlerGetAPITokens)
+	mux.HandleFunc("DELETE /api/tokens/{tokenID}", cfg.handlerRevokeAPIToken)
 
 	mux.HandleFunc("GET /api/chirps", cfg.handlerGetAllChirps)
 	mux.HandleFunc("POST /api/chirps", cfg.handlerCreateChirp)
+	mux.HandleFunc("GET /api/chirps/search", cfg.handlerSearchChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handlerGetChirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", cfg.handlerUpdateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.handlerDeleteChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", cfg.handlerGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.handlerGetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", cfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.handlerUnlikeChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.handlerGetChirpLikes)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", cfg.handlerRechirp)
+
+	mux.HandleFunc("GET /api/timeline", cfg.handlerGetTimeline)
+
+	mux.HandleFunc("GET /api/hashtags/trending", cfg.handlerGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handlerGetHashtagChirps)
 
 	mux.HandleFunc("GET /admin/metrics", cfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", cfg.handlerReset)
@@ -84,7 +187,132 @@ func main() {
 		Addr:    ":" + port,
 		Handler: mux,
 	}
+	reloadKeyringOnHangup(jwtKeyring)
+
 	log.Printf("Serving files from %v on port: %v", filePathRoot, port)
 	log.Fatal(srv.ListenAndServe())
 	defer srv.Close()
 }
+
+// durationFromEnv reads a duration such as "15m" or "720h" from the
+// environment, falling back to def when the variable is unset.
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a non-negative duration like 15m or 720h: %q", key, value)
+	}
+	return d
+}
+
+// argon2ParamsFromEnv lets the cost of password hashing be tuned to the
+// hardware. Existing hashes are upgraded to new parameters on login.
+func argon2ParamsFromEnv() auth.Argon2Params {
+	params := auth.DefaultArgon2Params
+	params.Memory = uint32(uintFromEnv("ARGON2_MEMORY_KIB", uint64(params.Memory), 32))
+	params.Iterations = uint32(uintFromEnv("ARGON2_ITERATIONS", uint64(params.Iterations), 32))
+	params.Parallelism = uint8(uintFromEnv("ARGON2_PARALLELISM", uint64(params.Parallelism), 8))
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		log.Fatalf("ARGON2_MEMORY_KIB must be at least 8 per thread, and ARGON2_ITERATIONS and ARGON2_PARALLELISM at least 1")
+	}
+	return params
+}
+
+// passwordPolicyFromEnv sets the rules for new passwords. The breached
+// password list is optional because it is large and has to be downloaded; it
+// must be the list ordered by hash, which is searched in place on disk.
+func passwordPolicyFromEnv() *auth.PasswordPolicy {
+	policy := &auth.PasswordPolicy{
+		MinLength: int(uintFromEnv("PASSWORD_MIN_LENGTH", 8, 16)),
+		MinScore:  int(uintFromEnv("PASSWORD_MIN_SCORE", 2, 8)),
+	}
+	if policy.MinScore > 4 {
+		log.Fatalf("PASSWORD_MIN_SCORE must be between 0 and 4")
+	}
+
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		breached, err := auth.OpenBreachedPasswords(path)
+		if err != nil {
+			log.Fatalf("error opening breached passwords: %v", err)
+		}
+		policy.Breached = breached
+	}
+	return policy
+}
+
+// uintFromEnv reads an unsigned integer of the given bit size from the
+// environment, falling back to def when the variable is unset.
+func uintFromEnv(key string, def uint64, bitSize int) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil {
+		log.Fatalf("%s must be a non-negative integer: %q", key, value)
+	}
+	return n
+}
+
+// boolFromEnv reads a boolean such as "true" or "0" from the environment,
+// falling back to def when the variable is unset.
+func boolFromEnv(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be true or false: %q", key, value)
+	}
+	return b
+}
+
+// keyringFromEnv loads the JWT signing keys from JWT_KEYRING_FILE, or falls
+// back to a single key from JWT_SECRET.
+func keyringFromEnv() *auth.Keyring {
+	if path := os.Getenv("JWT_KEYRING_FILE"); path != "" {
+		keyring, err := auth.LoadKeyring(path)
+		if err != nil {
+			log.Fatalf("error loading JWT keyring: %v", err)
+		}
+		return keyring
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatalf("JWT_SECRET or JWT_KEYRING_FILE environment variable is required")
+	}
+	keyring, err := auth.NewKeyringFromSecret(jwtSecret)
+	if err != nil {
+		log.Fatalf("error creating JWT keyring: %v", err)
+	}
+	return keyring
+}
+
+// reloadKeyringOnHangup rereads a file-backed keyring on SIGHUP, so keys can
+// be rotated without a restart.
+func reloadKeyringOnHangup(keyring *auth.Keyring) {
+	if os.Getenv("JWT_KEYRING_FILE") == "" {
+		return
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			err := keyring.Reload()
+			if err != nil {
+				log.Printf("could not reload JWT keyring: %v", err)
+				continue
+			}
+			log.Printf("reloaded JWT keyring")
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([A-Za-z0-9_]+)`)
+
+// extractMentions returns the distinct, lower-cased handles mentioned in a
+// chirp body. Handles that could never be valid usernames are skipped.
+func extractMentions(body string) []string {
+	handles := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(match[1])
+		if validateUsername(handle) != nil {
+			continue
+		}
+		if _, ok := seen[handle]; ok {
+			continue
+		}
+		seen[handle] = struct{}{}
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// syncChirpMentions resolves the handles in body to users and records them as
+// mentioned by the chirp. Handles that don't belong to anyone are ignored.
+func syncChirpMentions(ctx context.Context, q *database.Queries, chirpID uuid.UUID, body string) error {
+	err := q.DeleteChirpMentions(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+
+	handles := extractMentions(body)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	return q.CreateChirpMentions(ctx, database.CreateChirpMentionsParams{
+		ChirpID:   chirpID,
+		Usernames: handles,
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No mentions",
+			body: "just a chirp",
+			want: []string{},
+		},
+		{
+			name: "Mentions in order of appearance",
+			body: "@walt says hi to @jesse",
+			want: []string{"walt", "jesse"},
+		},
+		{
+			name: "Trailing punctuation",
+			body: "thanks @walt, @jesse! and @saul.",
+			want: []string{"walt", "jesse", "saul"},
+		},
+		{
+			name: "Wrapped in brackets",
+			body: "(@walt) [@jesse]",
+			want: []string{"walt", "jesse"},
+		},
+		{
+			name: "Possessive",
+			body: "@walt's lab",
+			want: []string{"walt"},
+		},
+		{
+			name: "Case folded duplicates",
+			body: "@Walt @walt @WALT",
+			want: []string{"walt"},
+		},
+		{
+			name: "Email address",
+			body: "mail walt@example.com",
+			want: []string{},
+		},
+		{
+			name: "Inside a word",
+			body: "meet me at4@walt",
+			want: []string{},
+		},
+		{
+			name: "Doubled at sign",
+			body: "@@walt",
+			want: []string{"walt"},
+		},
+		{
+			name: "Too short to be a username",
+			body: "@me @ab",
+			want: []string{},
+		},
+		{
+			name: "Too long to be a username",
+			body: "@abcdefghijklmnopqrstu",
+			want: []string{},
+		},
+		{
+			name: "Non-ASCII handle",
+			body: "@élodie",
+			want: []string{},
+		},
+		{
+			name: "Bare at sign",
+			body: "@ home",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractMentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// chirpCursor marks the last chirp of a page. Pages are ordered by
+// (created_at, id), so the pair is enough to resume from exactly where the
+// previous page stopped.
+type chirpCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeChirpCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeChirpCursor returns a nil cursor for an empty string, meaning "start
+// from the first page".
+func decodeChirpCursor(s string) (*chirpCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	createdAtString, idString, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	return &chirpCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+func (c *chirpCursor) createdAt() sql.NullTime {
+	if c == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: c.CreatedAt, Valid: true}
+}
+
+func (c *chirpCursor) id() uuid.NullUUID {
+	if c == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: c.ID, Valid: true}
+}
+
+func parsePageLimit(s string) (int32, error) {
+	if s == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return int32(limit), nil
+}
+
+// setNextPageLink points clients at the next page using the same query
+// parameters as the current request, with the cursor swapped out.
+func setNextPageLink(w http.ResponseWriter, r *http.Request, cursor string) {
+	next := *r.URL
+	query := next.Query()
+	query.Set("cursor", cursor)
+	next.RawQuery = query.Encode()
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChirpCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		id        uuid.UUID
+	}{
+		{
+			name:      "Nanosecond precision",
+			createdAt: time.Date(2024, 3, 9, 14, 30, 5, 123456789, time.UTC),
+			id:        uuid.New(),
+		},
+		{
+			name:      "Whole seconds",
+			createdAt: time.Date(2024, 3, 9, 14, 30, 5, 0, time.UTC),
+			id:        uuid.New(),
+		},
+		{
+			name:      "Nil ID",
+			createdAt: time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC),
+			id:        uuid.Nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeChirpCursor(encodeChirpCursor(tt.createdAt, tt.id))
+			if err != nil {
+				t.Fatalf("decodeChirpCursor() error = %v", err)
+			}
+			if !cursor.CreatedAt.Equal(tt.createdAt) {
+				t.Errorf("decodeChirpCursor() CreatedAt = %v, want %v", cursor.CreatedAt, tt.createdAt)
+			}
+			if cursor.ID != tt.id {
+				t.Errorf("decodeChirpCursor() ID = %v, want %v", cursor.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeChirpCursor(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name      string
+		cursor    string
+		wantNil   bool
+		wantError bool
+	}{
+		{
+			name:    "Empty cursor starts from the first page",
+			cursor:  "",
+			wantNil: true,
+		},
+		{
+			name:      "Not base64",
+			cursor:    "not base64!",
+			wantError: true,
+		},
+		{
+			name:      "Missing separator",
+			cursor:    encode("2024-03-09T14:30:05Z" + uuid.Nil.String()),
+			wantError: true,
+		},
+		{
+			name:      "Bad timestamp",
+			cursor:    encode("yesterday|" + uuid.Nil.String()),
+			wantError: true,
+		},
+		{
+			name:      "Bad ID",
+			cursor:    encode("2024-03-09T14:30:05Z|not-a-uuid"),
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeChirpCursor(tt.cursor)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("decodeChirpCursor() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantNil && cursor != nil {
+				t.Errorf("decodeChirpCursor() = %v, want nil", cursor)
+			}
+			if tt.wantNil && (cursor.createdAt().Valid || cursor.id().Valid) {
+				t.Errorf("nil cursor should bind as NULL query parameters")
+			}
+		})
+	}
+}
+
+func TestParsePageLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     string
+		want      int32
+		wantError bool
+	}{
+		{
+			name:  "Default",
+			limit: "",
+			want:  defaultPageLimit,
+		},
+		{
+			name:  "Within bounds",
+			limit: "20",
+			want:  20,
+		},
+		{
+			name:  "Minimum",
+			limit: "1",
+			want:  1,
+		},
+		{
+			name:  "Maximum",
+			limit: "100",
+			want:  maxPageLimit,
+		},
+		{
+			name:  "Above maximum is capped",
+			limit: "1000",
+			want:  maxPageLimit,
+		},
+		{
+			name:      "Zero",
+			limit:     "0",
+			wantError: true,
+		},
+		{
+			name:      "Negative",
+			limit:     "-5",
+			wantError: true,
+		},
+		{
+			name:      "Non-numeric",
+			limit:     "ten",
+			wantError: true,
+		},
+		{
+			name:      "Fractional",
+			limit:     "2.5",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePageLimit(tt.limit)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("parsePageLimit() error = %v, wantError %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("parsePageLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
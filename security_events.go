@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	securityEventRefreshTokenReuse = "refresh_token_reuse"
+	securityEventPasswordChanged   = "password_changed"
+	securityEventPasswordReset     = "password_reset"
+	securityEventTOTPEnabled       = "totp_enabled"
+	securityEventRecoveryCodeUsed  = "recovery_code_used"
+	securityEventDeletionRequested = "deletion_requested"
+	securityEventDeletionCancelled = "deletion_cancelled"
+)
+
+// recordSecurityEvent logs a security-relevant event and keeps it in the
+// user's audit trail. Failing to store the event never fails the request.
+func (cfg *apiConfig) recordSecurityEvent(ctx context.Context, userID uuid.UUID, eventType, details string) {
+	log.Printf("security event %s for user %s: %s", eventType, userID, details)
+
+	err := cfg.db.CreateSecurityEvent(ctx, database.CreateSecurityEventParams{
+		UserID:    userID,
+		EventType: eventType,
+		Details:   details,
+	})
+	if err != nil {
+		log.Printf("could not record security event %s for user %s: %v", eventType, userID, err)
+	}
+}
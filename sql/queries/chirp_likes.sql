@@ -0,0 +1,27 @@
+-- name: LikeChirp :exec
+INSERT INTO chirp_likes (chirp_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+) ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE chirp_id = $1
+AND user_id = $2;
+
+-- name: GetChirpLikes :many
+SELECT * FROM chirp_likes
+WHERE chirp_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetLikesByUser :many
+SELECT * FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at DESC;
@@ -1,11 +1,12 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 ) RETURNING *;
 
 -- name: DeleteChirps :exec
@@ -13,10 +14,12 @@ DELETE FROM chirps;
 
 -- name: GetChirpById :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL);
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
+WHERE chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
 ORDER BY created_at;
 
 -- name: DeleteChirp :exec
@@ -26,4 +29,145 @@ WHERE id = $1;
 -- name: GetChirpsByAuthor :many
 SELECT * FROM chirps
 WHERE user_id = $1
-ORDER BY created_at;
\ No newline at end of file
+ORDER BY created_at;
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND (sqlc.arg('include_rechirps')::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND (sqlc.arg('include_rechirps')::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchChirps :many
+SELECT * FROM chirps
+WHERE to_tsvector('english', body) @@ to_tsquery('english', sqlc.arg('query'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY ts_rank(to_tsvector('english', body), to_tsquery('english', sqlc.arg('query'))) DESC, created_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTimeline :many
+SELECT * FROM chirps
+WHERE (
+    user_id = sqlc.arg('user_id')
+    OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id'))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND (sqlc.arg('include_rechirps')::boolean OR rechirp_of IS NULL OR is_quote)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors(id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0
+    FROM chirps c
+    WHERE c.id = $1
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1
+    FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON chirps.id = ancestors.id
+WHERE ancestors.depth > 0
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpReplies :many
+WITH RECURSIVE replies(id, depth) AS (
+    SELECT c.id, 1
+    FROM chirps c
+    WHERE c.in_reply_to = sqlc.arg('chirp_id')
+    UNION ALL
+    SELECT c.id, r.depth + 1
+    FROM chirps c
+    JOIN replies r ON c.in_reply_to = r.id
+    WHERE r.depth < sqlc.arg('max_depth')
+)
+SELECT chirps.* FROM chirps
+JOIN replies ON chirps.id = replies.id
+WHERE chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg('limit');
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of, is_quote)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL AND NOT is_quote
+DO NOTHING
+RETURNING *;
+
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[])
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL);
+
+-- name: UpdateChirpBody :one
+WITH current_chirp AS (
+    SELECT id, body, updated_at FROM chirps
+    WHERE chirps.id = sqlc.arg('id')
+    FOR UPDATE
+), revision AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), current_chirp.id, current_chirp.body, current_chirp.updated_at
+    FROM current_chirp
+)
+UPDATE chirps
+SET body = sqlc.arg('body'), updated_at = NOW()
+FROM current_chirp
+WHERE chirps.id = current_chirp.id
+RETURNING chirps.*;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.name = sqlc.arg('name')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetMentions :many
+SELECT chirps.* FROM chirps
+JOIN chirp_mentions ON chirp_mentions.chirp_id = chirps.id
+WHERE chirp_mentions.user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
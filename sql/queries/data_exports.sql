@@ -0,0 +1,46 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, user_id, expires_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    sqlc.arg('user_id'),
+    NOW() + make_interval(secs => sqlc.arg('ttl_seconds')::float8)
+) RETURNING *;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports
+WHERE id = $1
+AND user_id = $2
+AND expires_at > NOW();
+
+-- name: GetPendingDataExport :one
+SELECT * FROM data_exports
+WHERE user_id = $1
+AND status = 'pending'
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', archive = $2, completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailStaleDataExports :exec
+UPDATE data_exports
+SET status = 'failed', completed_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND status = 'pending'
+AND created_at < NOW() - make_interval(secs => sqlc.arg('timeout_seconds')::float8);
+
+-- name: DeleteUserDataExports :exec
+DELETE FROM data_exports
+WHERE user_id = $1;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at < NOW();
@@ -0,0 +1,46 @@
+-- name: FollowUser :exec
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT $1, $2, NOW()
+WHERE NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE id IN ($1, $2)
+    AND deletion_requested_at IS NOT NULL
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT * FROM follows
+WHERE followee_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetFollowing :many
+SELECT * FROM follows
+WHERE follower_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListFollowers :many
+SELECT * FROM follows
+WHERE followee_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, follower_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND follower_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, follower_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListFollowing :many
+SELECT * FROM follows
+WHERE follower_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, followee_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid)
+)
+AND followee_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+ORDER BY created_at DESC, followee_id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,31 @@
+-- name: CreateHashtags :exec
+INSERT INTO hashtags (id, created_at, name)
+SELECT gen_random_uuid(), NOW(), unnest(sqlc.arg('names')::text[])
+ON CONFLICT (name) DO NOTHING;
+
+-- name: TagChirp :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+SELECT sqlc.arg('chirp_id'), id FROM hashtags
+WHERE name = ANY(sqlc.arg('names')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: UntagChirp :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: GetTrendingHashtags :many
+SELECT
+    hashtags.name,
+    COUNT(*) FILTER (WHERE chirps.created_at > NOW() - INTERVAL '1 hour') AS last_hour,
+    COUNT(*) AS last_day,
+    (COUNT(*) FILTER (WHERE chirps.created_at > NOW() - INTERVAL '1 hour'))::float8
+        / (COUNT(*)::float8 / 24) AS velocity
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirps.created_at > NOW() - INTERVAL '1 day'
+AND chirps.user_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)
+GROUP BY hashtags.name
+HAVING COUNT(*) FILTER (WHERE chirps.created_at > NOW() - INTERVAL '1 hour') > 0
+ORDER BY velocity DESC, last_hour DESC
+LIMIT $1;
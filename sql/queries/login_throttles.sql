@@ -0,0 +1,29 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE key = $1;
+
+-- name: ReserveLoginAttempt :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (sqlc.arg('key'), 1, sqlc.arg('attempted_at'))
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg('window_start') THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = EXCLUDED.last_failure_at
+WHERE login_throttles.last_failure_at < sqlc.arg('window_start')
+    OR login_throttles.last_failure_at + make_interval(secs => (sqlc.arg('lockout_seconds')::float8[])[LEAST(login_throttles.failures, cardinality(sqlc.arg('lockout_seconds')::float8[]) - 1) + 1]) <= sqlc.arg('attempted_at')
+RETURNING *;
+
+-- name: ReleaseLoginAttempt :exec
+UPDATE login_throttles
+SET failures = failures - 1
+WHERE key = $1 AND failures > 0;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE last_failure_at < $1;
@@ -0,0 +1,33 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (id, created_at, user_id, token_hash, expires_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3
+) RETURNING *;
+
+-- name: CountRecentPasswordResets :one
+SELECT COUNT(*) FROM password_resets
+WHERE user_id = $1
+AND created_at > $2;
+
+-- name: GetPasswordResetByToken :one
+SELECT * FROM password_resets
+WHERE token_hash = $1
+AND used_at IS NULL
+AND expires_at > NOW();
+
+-- name: UsePasswordReset :execrows
+UPDATE password_resets
+SET used_at = NOW()
+WHERE id = $1
+AND used_at IS NULL
+AND expires_at > NOW();
+
+-- name: InvalidatePasswordResets :exec
+UPDATE password_resets
+SET used_at = NOW()
+WHERE user_id = $1
+AND used_at IS NULL;
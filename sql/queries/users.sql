@@ -1,12 +1,16 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username, display_name, bio, location)
 VALUES(
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
-) RETURNING id, created_at, updated_at, email;
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+) RETURNING *;
 
 -- name: DeleteUsers :exec
 DELETE FROM users;
@@ -18,7 +22,8 @@ SELECT * FROM users WHERE email = $1;
 UPDATE users
 SET email = $1,
     updated_at = NOW(),
-    hashed_password = $2
+    hashed_password = $2,
+    email_verified_at = CASE WHEN email = $1 THEN email_verified_at END
 WHERE id = $3
 RETURNING *;
 
@@ -26,4 +31,82 @@ RETURNING *;
 UPDATE users
 SET is_chirpy_red = TRUE, updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserById :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET username = COALESCE(sqlc.narg('username'), username),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    location = COALESCE(sqlc.narg('location'), location),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetPublicProfile :one
+SELECT
+    users.id,
+    users.created_at,
+    users.username,
+    users.display_name,
+    users.bio,
+    users.location,
+    users.is_chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id
+        AND follows.follower_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id
+        AND follows.followee_id NOT IN (SELECT id FROM users WHERE deletion_requested_at IS NOT NULL)) AS following_count
+FROM users
+WHERE LOWER(users.username) = LOWER(sqlc.arg('username'))
+AND users.deletion_requested_at IS NULL;
+
+-- name: SetTotpSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW()
+WHERE id = $1;
+
+-- name: EnableTotp :exec
+UPDATE users
+SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UseTotpStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1
+AND totp_last_step < $2;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = sqlc.arg('new_hash'), updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND hashed_password = sqlc.arg('old_hash');
+
+-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND email = $2
+AND email_verified_at IS NULL;
+
+-- name: RequestUserDeletion :one
+UPDATE users
+SET deletion_requested_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND deletion_requested_at IS NULL
+RETURNING *;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_requested_at = NULL, updated_at = NOW()
+WHERE id = $1
+AND deletion_requested_at IS NOT NULL;
+
+-- name: PurgeDeletedUsers :many
+DELETE FROM users
+WHERE deletion_requested_at < NOW() - make_interval(secs => sqlc.arg('grace_period_seconds')::float8)
+RETURNING id;
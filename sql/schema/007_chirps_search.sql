@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN search TSVECTOR NOT NULL
+GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_idx ON chirps USING GIN (search);
+
+-- +goose Down
+DROP INDEX chirps_search_idx;
+ALTER TABLE chirps DROP COLUMN search;
@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN rechirp_of UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN is_quote BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX chirps_rechirp_of_idx ON chirps (rechirp_of);
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of)
+WHERE rechirp_of IS NOT NULL AND NOT is_quote;
+
+-- A pure rechirp has nothing of its own to show, so it is deleted together
+-- with the original. Quote chirps keep their text and are left as tombstones:
+-- the foreign key sets rechirp_of to NULL while is_quote stays TRUE.
+-- +goose StatementBegin
+CREATE FUNCTION chirps_delete_rechirps() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM chirps WHERE rechirp_of = OLD.id AND NOT is_quote;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_delete_rechirps
+BEFORE DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION chirps_delete_rechirps();
+
+-- +goose Down
+DROP TRIGGER chirps_delete_rechirps ON chirps;
+DROP FUNCTION chirps_delete_rechirps;
+DROP INDEX chirps_user_id_rechirp_of_idx;
+DROP INDEX chirps_rechirp_of_idx;
+ALTER TABLE chirps
+DROP COLUMN is_quote,
+DROP COLUMN rechirp_of;
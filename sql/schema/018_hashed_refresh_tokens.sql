@@ -0,0 +1,21 @@
+-- +goose Up
+-- Only a SHA-256 of each refresh token is kept. Existing tokens are hashed in
+-- place, so sessions survive the migration.
+ALTER TABLE refresh_tokens
+ADD COLUMN token_hash TEXT;
+
+UPDATE refresh_tokens
+SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_pkey;
+ALTER TABLE refresh_tokens DROP COLUMN token;
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE refresh_tokens ADD PRIMARY KEY (token_hash);
+
+-- +goose Down
+-- Plaintext tokens can't be recovered from their hashes, so rolling back
+-- logs everyone out.
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_pkey;
+ALTER TABLE refresh_tokens DROP COLUMN token_hash;
+ALTER TABLE refresh_tokens ADD COLUMN token TEXT PRIMARY KEY;
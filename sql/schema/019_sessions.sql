@@ -0,0 +1,24 @@
+-- +goose Up
+-- A session is a refresh token family. Each token carries the details captured
+-- at login forward, so the live token describes the whole session.
+ALTER TABLE refresh_tokens
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+ADD COLUMN session_started_at TIMESTAMP;
+
+UPDATE refresh_tokens
+SET session_started_at = (
+    SELECT MIN(family.created_at) FROM refresh_tokens AS family
+    WHERE family.family_id = refresh_tokens.family_id
+);
+
+ALTER TABLE refresh_tokens ALTER COLUMN session_started_at SET NOT NULL;
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+ALTER TABLE refresh_tokens
+DROP COLUMN session_started_at,
+DROP COLUMN ip_address,
+DROP COLUMN user_agent;
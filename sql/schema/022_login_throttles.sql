@@ -0,0 +1,13 @@
+-- +goose Up
+-- Failed login counts shared by every instance. key is "email:<address>",
+-- "ip:<address>" or "mfa:<user id>".
+CREATE TABLE login_throttles(
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX login_throttles_last_failure_at_idx ON login_throttles (last_failure_at);
+
+-- +goose Down
+DROP TABLE login_throttles;
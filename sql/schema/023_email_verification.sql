@@ -0,0 +1,22 @@
+-- +goose Up
+-- email_verified_at is set once the user follows a verification link, and
+-- cleared again when they change their email. Each row in
+-- email_verifications backs one signed verification token and is what makes
+-- that token single-use.
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMP;
+
+CREATE TABLE email_verifications(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    email TEXT NOT NULL,
+    used_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE email_verifications;
+ALTER TABLE users
+DROP COLUMN email_verified_at;
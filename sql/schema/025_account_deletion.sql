@@ -0,0 +1,14 @@
+-- +goose Up
+-- deletion_requested_at starts an account's grace period. Until it is purged
+-- the account can still log in and cancel, but its chirps and profile are
+-- hidden from everyone else.
+ALTER TABLE users
+ADD COLUMN deletion_requested_at TIMESTAMP;
+
+CREATE INDEX users_deletion_requested_at_idx ON users (deletion_requested_at)
+WHERE deletion_requested_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX users_deletion_requested_at_idx;
+ALTER TABLE users
+DROP COLUMN deletion_requested_at;
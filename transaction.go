@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/brettlazarine/Chirpy/internal/database"
+)
+
+// withTx runs fn with queries that all belong to one transaction, which is
+// committed only if fn succeeds.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(cfg.db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}